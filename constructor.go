@@ -0,0 +1,96 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"fmt"
+	"sync"
+	"syscall/js"
+)
+
+// cachedNames are the global constructors used by conversions, which are looked up once and cached as they are not
+// expected to change.
+var cachedNames = []string{
+	"Array", "ArrayBuffer", "BigInt", "Date", "Error", "Map", "Object", "Promise", "Set", "String", "URLSearchParams",
+	"Uint8Array", "Int8Array", "Int16Array", "Int32Array", "Uint16Array", "Uint32Array", "Float32Array", "Float64Array",
+}
+
+// cachedConstructor is a global constructor that is looked up once.
+type cachedConstructor struct {
+	name  string
+	once  sync.Once
+	value js.Value
+	err   error
+}
+
+// get returns the constructor, looking it up on the first call.
+func (c *cachedConstructor) get() (js.Value, error) {
+	c.once.Do(func() {
+		c.value, c.err = lookupConstructor(c.name)
+	})
+	return c.value, c.err
+}
+
+var (
+	constructorsMu sync.RWMutex
+	constructors   = newConstructorCache()
+)
+
+// newConstructorCache returns an empty cache of the constructors in cachedNames.
+func newConstructorCache() map[string]*cachedConstructor {
+	cache := make(map[string]*cachedConstructor, len(cachedNames))
+	for _, name := range cachedNames {
+		cache[name] = &cachedConstructor{name: name}
+	}
+	return cache
+}
+
+// InvalidateConstructorCache forgets the global constructors such as Array and Object that were looked up and cached
+// by previous conversions, so that the next conversions look them up again.
+// It is meant for tests replacing these globals.
+func InvalidateConstructorCache() {
+	constructorsMu.Lock()
+	defer constructorsMu.Unlock()
+	constructors = newConstructorCache()
+}
+
+// constructor returns the global JS constructor with the provided name.
+func constructor(name string) (js.Value, error) {
+	constructorsMu.RLock()
+	cached, ok := constructors[name]
+	constructorsMu.RUnlock()
+
+	if !ok {
+		return lookupConstructor(name)
+	}
+	return cached.get()
+}
+
+// constructorProperty returns the static property key of the global JS constructor with the provided name, such as
+// Symbol.iterator, returning a TypeMismatchError if it does not have the expected type. Object.Get cannot reach it as
+// it only descends through objects, and constructors are functions.
+func constructorProperty(name, key string, expected js.Type) (js.Value, error) {
+	ctor, err := constructor(name)
+	if err != nil {
+		return js.Value{}, err
+	}
+
+	value := ctor.Get(key)
+	if value.Type() != expected {
+		return js.Value{}, TypeMismatchError{
+			Expected: expected,
+			Actual:   value.Type(),
+		}
+	}
+	return value, nil
+}
+
+// lookupConstructor looks up the global JS constructor with the provided name.
+func lookupConstructor(name string) (js.Value, error) {
+	value, err := Global().Expect(js.TypeFunction, name)
+	if err != nil {
+		return js.Value{}, fmt.Errorf("%s constructor not found: %w", name, err)
+	}
+	return value, nil
+}
@@ -0,0 +1,29 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"syscall/js"
+	"testing"
+)
+
+func TestInvalidateConstructorCache(t *testing.T) {
+	original := js.Global().Get("Set")
+	ToJSSet([]int{1})
+
+	js.Global().Set("Set", jsFunc("Base", `return class TestSet extends Base {}`).Invoke(original))
+	t.Cleanup(func() {
+		js.Global().Set("Set", original)
+		InvalidateConstructorCache()
+	})
+
+	// The cached constructor is used until the cache is invalidated.
+	if got := ToJSSet([]int{1}).Get("constructor").Get("name").String(); got != "Set" {
+		t.Errorf("constructor before InvalidateConstructorCache = %s, want Set", got)
+	}
+	InvalidateConstructorCache()
+	if got := ToJSSet([]int{1}).Get("constructor").Get("name").String(); got != "TestSet" {
+		t.Errorf("constructor after InvalidateConstructorCache = %s, want TestSet", got)
+	}
+}
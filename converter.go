@@ -0,0 +1,121 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"sync"
+	"syscall/js"
+)
+
+// Converter converts Go values into JS values like ToJSValue while keeping track of every js.Func it creates, such as
+// the ones wrapping struct methods and functions, including the functions held by maps such as a map[string]func() of
+// event handlers, slices and struct fields, so that they can be released together.
+//
+// A js.Func that is never released leaks its Go callback for as long as the WASM instance runs. Values converted with
+// ToJSValue are never released, which is fine for values that live as long as the program. Conversions that are
+// discarded at some point should use a Converter instead and call Release once JS no longer uses the converted values.
+//
+// The zero value of Converter is ready to use. It is safe for concurrent use.
+type Converter struct {
+	mu    sync.Mutex
+	funcs []js.Func
+}
+
+// NewConverter returns a new Converter.
+func NewConverter() *Converter {
+	return &Converter{}
+}
+
+// ToJSValue converts a given Go value into its equivalent JS form like ToJSValue.
+func (c *Converter) ToJSValue(x interface{}) js.Value {
+	value, err := c.ToJSValueErr(x)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// ToJSValueErr converts a given Go value into its equivalent JS form like ToJSValueErr.
+func (c *Converter) ToJSValueErr(x interface{}) (js.Value, error) {
+	e := encoder{config: currentConfig(), converter: c}
+	return e.toJSValue(x)
+}
+
+// AsyncFunc converts the provided Go function into a JS function returning a Promise like AsyncFunc, keeping track of
+// its js.Func and of the ones created by the conversions of its return values.
+func (c *Converter) AsyncFunc(fn interface{}) js.Value {
+	e := encoder{config: currentConfig(), converter: c}
+	return e.asyncFunc(fn)
+}
+
+// Release releases every js.Func created by the Converter so far. Calling a released function from JS no longer calls
+// into Go.
+// The Converter can still be used afterwards.
+func (c *Converter) Release() {
+	c.mu.Lock()
+	funcs := c.funcs
+	c.funcs = nil
+	c.mu.Unlock()
+
+	for _, f := range funcs {
+		f.Release()
+	}
+}
+
+// track records the provided js.Func to be released by Release.
+func (c *Converter) track(f js.Func) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.funcs = append(c.funcs, f)
+}
+
+// funcOf returns js.FuncOf(fn), tracking it in the encoder's Converter if there is one.
+func (e *encoder) funcOf(fn func(this js.Value, args []js.Value) interface{}) js.Func {
+	f := js.FuncOf(fn)
+	if e.converter != nil {
+		e.converter.track(f)
+	}
+	return f
+}
+
+// Scope is a lifecycle boundary for the JS resources allocated by conversions, such as a render or a request: the
+// js.Funcs created by the conversions made through it are all released by Close.
+//
+//	scope := gowasm.NewScope()
+//	defer scope.Close()
+//	render(scope.ToJSValue(props))
+//
+// It is a Converter whose only way to release its functions is Close, which makes the end of the scope explicit.
+// It is safe for concurrent use.
+type Scope struct {
+	converter Converter
+}
+
+// NewScope returns a new Scope.
+func NewScope() *Scope {
+	return &Scope{}
+}
+
+// ToJSValue converts a given Go value into its equivalent JS form like ToJSValue, recording the js.Funcs it creates.
+func (s *Scope) ToJSValue(x interface{}) js.Value {
+	return s.converter.ToJSValue(x)
+}
+
+// ToJSValueErr converts a given Go value into its equivalent JS form like ToJSValueErr, recording the js.Funcs it
+// creates.
+func (s *Scope) ToJSValueErr(x interface{}) (js.Value, error) {
+	return s.converter.ToJSValueErr(x)
+}
+
+// AsyncFunc converts the provided Go function into a JS function returning a Promise like AsyncFunc, recording the
+// js.Funcs it creates.
+func (s *Scope) AsyncFunc(fn interface{}) js.Value {
+	return s.converter.AsyncFunc(fn)
+}
+
+// Close releases every js.Func created by the conversions made through the Scope, after which calling them from JS no
+// longer calls into Go. Conversions made after Close are recorded for the next call to Close.
+func (s *Scope) Close() {
+	s.converter.Release()
+}
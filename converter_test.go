@@ -0,0 +1,106 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestConverterMapOfFuncs(t *testing.T) {
+	var clicked, hovered int
+	handlers := map[string]func(){
+		"click": func() { clicked++ },
+		"hover": func() { hovered++ },
+	}
+
+	c := NewConverter()
+	obj := c.ToJSValue(handlers)
+	obj.Call("click")
+	obj.Call("hover")
+	obj.Call("hover")
+	if clicked != 1 || hovered != 2 {
+		t.Errorf("clicked %d and hovered %d times, want 1 and 2", clicked, hovered)
+	}
+
+	if got := len(c.funcs); got != 2 {
+		t.Errorf("Converter tracks %d functions, want 2", got)
+	}
+	c.Release()
+	if got := len(c.funcs); got != 0 {
+		t.Errorf("Converter tracks %d functions after Release, want 0", got)
+	}
+}
+
+func TestConverterAsyncFunc(t *testing.T) {
+	c := NewConverter()
+	double := c.AsyncFunc(func(n int) (int, error) {
+		if n < 0 {
+			return 0, errors.New("negative")
+		}
+		return 2 * n, nil
+	})
+
+	value, err := Await(double.Invoke(2))
+	if err != nil {
+		t.Fatalf("Await() error = %v", err)
+	}
+	if got := value.Int(); got != 4 {
+		t.Errorf("double(2) = %d, want 4", got)
+	}
+	if _, err := Await(double.Invoke(-1)); err == nil {
+		t.Errorf("Await() of double(-1) error = nil, want the rejection")
+	}
+
+	if got := len(c.funcs); got != 1 {
+		t.Errorf("Converter tracks %d functions, want 1", got)
+	}
+	c.Release()
+	if got := double.Invoke(2); !got.IsUndefined() {
+		t.Errorf("double(2) after Release = %v, want undefined", got)
+	}
+}
+
+func TestScopeClose(t *testing.T) {
+	type props struct {
+		OnClick  func() int
+		Children []func() int
+	}
+	calls := 0
+	count := func() int {
+		calls++
+		return calls
+	}
+
+	scope := NewScope()
+	obj := scope.ToJSValue(props{OnClick: count, Children: []func() int{count, count}})
+	if got := obj.Call("OnClick").Int(); got != 1 {
+		t.Errorf("OnClick() = %d, want 1", got)
+	}
+	if got := len(scope.converter.funcs); got != 3 {
+		t.Errorf("Scope tracks %d functions, want 3", got)
+	}
+
+	scope.Close()
+	if got := len(scope.converter.funcs); got != 0 {
+		t.Errorf("Scope tracks %d functions after Close, want 0", got)
+	}
+	// The wrapper of a released function throws, as it gets undefined instead of a result.
+	if _, ok := catch(obj.Get("OnClick")); !ok {
+		t.Errorf("OnClick() after Close did not throw")
+	}
+	if calls != 1 {
+		t.Errorf("count was called %d times, want 1", calls)
+	}
+
+	// The Scope keeps recording the conversions made after Close for the next one.
+	fn := scope.ToJSValue(count)
+	if got := fn.Invoke().Int(); got != 2 {
+		t.Errorf("fn() = %d, want 2", got)
+	}
+	if got := len(scope.converter.funcs); got != 1 {
+		t.Errorf("Scope tracks %d functions after a new conversion, want 1", got)
+	}
+	scope.Close()
+}
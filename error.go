@@ -3,14 +3,48 @@
 
 package gowasm
 
-import "syscall/js"
+import (
+	"fmt"
+	"syscall/js"
+)
 
 // NewError returns a JS Error with the provided Go error's error message.
 func NewError(goErr error) js.Value {
-	errConstructor, err := Global().Expect(js.TypeFunction, "Error")
+	errConstructor, err := constructor("Error")
 	if err != nil {
-		panic("Error constructor not found")
+		panic(err)
 	}
 
 	return errConstructor.New(goErr.Error())
 }
+
+// ToJSError converts the provided Go error into a JS Error with the same message like NewError, keeping the chain of
+// errors it wraps for JS to inspect: the error returned by its Unwrap method, if any, is converted the same way into
+// the cause property, and the errors returned by an Unwrap method returning a []error, like the ones of errors.Join,
+// into an errors array as AggregateError has. The goType property names the concrete Go type of the error, such as
+// "*fs.PathError".
+//
+// A nil error is converted into null.
+func ToJSError(err error) js.Value {
+	if err == nil {
+		return js.Null()
+	}
+
+	jsErr := NewError(err)
+	jsErr.Set("goType", fmt.Sprintf("%T", err))
+
+	switch wrapper := err.(type) {
+	case interface{ Unwrap() error }:
+		if cause := wrapper.Unwrap(); cause != nil {
+			jsErr.Set("cause", ToJSError(cause))
+		}
+	case interface{ Unwrap() []error }:
+		causes := wrapper.Unwrap()
+		errs := make([]interface{}, len(causes))
+		for i, cause := range causes {
+			errs[i] = ToJSError(cause)
+		}
+		jsErr.Set("errors", errs)
+	}
+	return jsErr
+}
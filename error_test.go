@@ -0,0 +1,54 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type testNotFoundError struct {
+	Name string
+}
+
+func (e *testNotFoundError) Error() string {
+	return e.Name + " not found"
+}
+
+func TestToJSError(t *testing.T) {
+	// chain describes an error and its causes as "goType: message" lines, checking that each is an Error.
+	chain := jsFunc("e", `const lines = [];
+		for (; e !== undefined; e = e.cause) {
+			if (!(e instanceof Error)) return "not an Error: " + e;
+			lines.push(e.goType + ": " + e.message);
+		}
+		return lines.join("\n");`)
+
+	notFound := &testNotFoundError{Name: "user"}
+	err := fmt.Errorf("loading profile: %w", fmt.Errorf("querying: %w", notFound))
+	want := "*fmt.wrapError: loading profile: querying: user not found\n" +
+		"*fmt.wrapError: querying: user not found\n" +
+		"*gowasm.testNotFoundError: user not found"
+	if got := chain.Invoke(ToJSError(err)).String(); got != want {
+		t.Errorf("chain of ToJSError() = %q, want %q", got, want)
+	}
+
+	joined := ToJSError(errors.Join(errors.New("a"), notFound))
+	if got := joined.Get("goType").String(); got != "*errors.joinError" {
+		t.Errorf("goType of a joined error = %s, want *errors.joinError", got)
+	}
+	errs := joined.Get("errors")
+	if errs.Length() != 2 || errs.Index(0).Get("message").String() != "a" ||
+		errs.Index(1).Get("goType").String() != "*gowasm.testNotFoundError" {
+		t.Errorf("errors of a joined error = %v, want the two joined errors", errs)
+	}
+
+	if got := ToJSError(nil); !got.IsNull() {
+		t.Errorf("ToJSError(nil) = %v, want null", got)
+	}
+	if got := ToJSError(errors.New("flat")); !got.Get("cause").IsUndefined() {
+		t.Errorf("cause of an error wrapping nothing = %v, want undefined", got.Get("cause"))
+	}
+}
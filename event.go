@@ -0,0 +1,35 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"sync"
+	"syscall/js"
+)
+
+// AddEventListener registers fn as a listener of the provided event on target, such as a DOM element, by calling its
+// addEventListener method. fn is called with the event object.
+//
+// The returned function removes the listener with removeEventListener and releases the js.Func created for it, which a
+// listener converted with ToJSValue would otherwise leak for the lifetime of the WASM instance. It can be called more
+// than once, but only removes the listener the first time.
+func AddEventListener(target js.Value, event string, fn func(js.Value)) (remove func()) {
+	listener := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		arg := js.Undefined()
+		if len(args) != 0 {
+			arg = args[0]
+		}
+		fn(arg)
+		return nil
+	})
+	target.Call("addEventListener", event, listener)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			target.Call("removeEventListener", event, listener)
+			listener.Release()
+		})
+	}
+}
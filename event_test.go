@@ -0,0 +1,36 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"syscall/js"
+	"testing"
+)
+
+func TestAddEventListener(t *testing.T) {
+	target := js.Global().Get("EventTarget").New()
+	dispatch := func(event string) {
+		target.Call("dispatchEvent", js.Global().Get("Event").New(event))
+	}
+
+	var types []string
+	remove := AddEventListener(target, "ping", func(event js.Value) {
+		types = append(types, event.Get("type").String())
+	})
+	dispatch("ping")
+	dispatch("pong")
+	dispatch("ping")
+	if len(types) != 2 || types[0] != "ping" || types[1] != "ping" {
+		t.Errorf("received %v, want [ping ping]", types)
+	}
+
+	remove()
+	dispatch("ping")
+	if len(types) != 2 {
+		t.Errorf("received %d events after removing the listener, want 2", len(types))
+	}
+
+	// Removing the listener again does nothing, although its js.Func is already released.
+	remove()
+}
@@ -0,0 +1,173 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// structField describes a struct field that is converted into a JS property.
+type structField struct {
+	name      string // Name of the JS property.
+	goName    string // Name of the Go field.
+	index     []int  // Index sequence of the field for reflect.Value.FieldByIndex.
+	tagged    bool   // Whether the JS name comes from a wasm struct tag.
+	omitEmpty bool
+	asString  bool
+	format    string // Value of the format option, used for time.Time fields.
+	private   bool   // Whether the Go field is unexported, which is only included with IncludePrivate.
+}
+
+// fieldCacheKey identifies the arguments of a call to structFields.
+type fieldCacheKey struct {
+	typ            reflect.Type
+	includePrivate bool
+}
+
+// fieldCache maps the arguments of structFields to its result.
+var fieldCache sync.Map
+
+// cachedStructFields is like structFields but only computes the fields of each struct type once, as tag parsing and
+// promoting embedded fields would otherwise dominate the conversion of many values of the same type.
+func cachedStructFields(t reflect.Type, includePrivate bool) []structField {
+	key := fieldCacheKey{typ: t, includePrivate: includePrivate}
+	if fields, ok := fieldCache.Load(key); ok {
+		return fields.([]structField)
+	}
+	fields, _ := fieldCache.LoadOrStore(key, structFields(t, includePrivate))
+	return fields.([]structField)
+}
+
+// structFields returns the fields of the provided struct type that are converted into JS properties, ordered by their
+// position in the struct.
+//
+// The exported fields of anonymous embedded structs, or pointers to structs, without a name in their wasm tag are
+// promoted to the embedding struct, following the rules of encoding/json: a field that is less nested hides fields of
+// the same name that are more nested, and fields with the same name at the same depth hide each other unless exactly
+// one of them is tagged. Embedded fields that are not promotable, such as a js.Value or a time.Time, are converted
+// whole under their type name.
+// Unexported fields are skipped unless includePrivate is true.
+func structFields(t reflect.Type, includePrivate bool) []structField {
+	type embedded struct {
+		typ   reflect.Type
+		index []int
+	}
+
+	var fields []structField
+	seenNames := make(map[string]bool)
+	seenTypes := make(map[reflect.Type]bool)
+
+	for current := []embedded{{typ: t}}; len(current) > 0; {
+		var next []embedded
+		var depthFields []structField
+		depthCount := make(map[string]int)
+		depthTagged := make(map[string]int)
+
+		for _, emb := range current {
+			if seenTypes[emb.typ] {
+				continue
+			}
+			seenTypes[emb.typ] = true
+
+			for i := 0; i < emb.typ.NumField(); i++ {
+				field := emb.typ.Field(i)
+				tag := field.Tag.Get("wasm")
+				if tag == "-" {
+					continue
+				}
+
+				name, opts := parseTag(tag)
+				index := make([]int, len(emb.index)+1)
+				copy(index, emb.index)
+				index[len(emb.index)] = i
+
+				if field.Anonymous && name == "" && promotable(field.Type) {
+					if field.Type.Kind() == reflect.Ptr {
+						next = append(next, embedded{typ: field.Type.Elem(), index: index})
+					} else {
+						next = append(next, embedded{typ: field.Type, index: index})
+					}
+					continue
+				}
+				private := field.PkgPath != ""
+				if private && !includePrivate {
+					continue
+				}
+
+				format, _ := opts.Get("format")
+				tagged := name != ""
+				if !tagged {
+					name = field.Name
+				}
+				if seenNames[name] {
+					continue
+				}
+
+				depthCount[name]++
+				if tagged {
+					depthTagged[name]++
+				}
+				depthFields = append(depthFields, structField{
+					name:      name,
+					goName:    field.Name,
+					index:     index,
+					tagged:    tagged,
+					omitEmpty: opts.Contains("omitempty"),
+					asString:  opts.Contains("string"),
+					format:    format,
+					private:   private,
+				})
+			}
+		}
+
+		for _, field := range depthFields {
+			if depthCount[field.name] > 1 && (depthTagged[field.name] != 1 || !field.tagged) {
+				continue
+			}
+			fields = append(fields, field)
+		}
+		for name := range depthCount {
+			seenNames[name] = true
+		}
+
+		current = next
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i].index, fields[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return fields
+}
+
+// promotable reports whether the fields of an anonymous embedded field of type t are promoted to the embedding struct,
+// which is the case for structs and pointers to structs that are converted according to their fields rather than
+// specially, like a js.Value, or by their own methods or a registered converter.
+func promotable(t reflect.Type) bool {
+	if hasCustomConversion(t) {
+		return false
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		if hasCustomConversion(t) {
+			return false
+		}
+	}
+	return t.Kind() == reflect.Struct && !specialStructTypes[t]
+}
+
+// invalidateFieldCache forgets the fields computed by cachedStructFields, which depend on the registered converters.
+func invalidateFieldCache() {
+	fieldCache.Range(func(key, _ interface{}) bool {
+		fieldCache.Delete(key)
+		return true
+	})
+}
@@ -0,0 +1,49 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"reflect"
+	"syscall/js"
+)
+
+// ToJSFrozen converts a given Go value like ToJSValue and freezes the result with Object.freeze, so that JS code cannot
+// accidentally modify data shared with Go: the objects and arrays created by the conversion, including the ones nested
+// in the result, become immutable. JS values that are not created by the conversion, such as js.Value fields and the
+// results of Wrapper and JSMarshaler implementations, are left as they are, as are the functions the conversion
+// creates. Maps and Dates can still be modified through their methods, and typed arrays such as the Uint8Array of a
+// []byte are left writable, as JS cannot freeze them. Struct methods are attached eagerly even if SetLazyMethods is
+// enabled, as the getters of lazy methods cannot replace themselves on a frozen object.
+//
+// Every created object is frozen with a call to Object.freeze once the conversion is done, which roughly doubles the
+// cost of the conversion for large values.
+//
+// It panics when ToJSValue would. Use ToJSFrozenErr to get an error instead.
+func ToJSFrozen(x interface{}) js.Value {
+	value, err := ToJSFrozenErr(x)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// ToJSFrozenErr is like ToJSFrozen but returns a ConversionError instead of panicking.
+func ToJSFrozenErr(x interface{}) (js.Value, error) {
+	e := encoder{config: currentConfig(), freeze: true}
+	e.config.lazyMethods = false
+
+	freeze, err := constructorProperty("Object", "freeze", js.TypeFunction)
+	if err != nil {
+		return js.Value{}, e.errorf(reflect.ValueOf(x), err)
+	}
+
+	value, err := e.toJSValue(x)
+	if err != nil {
+		return js.Value{}, err
+	}
+	for _, obj := range e.created {
+		freeze.Invoke(obj)
+	}
+	return value, nil
+}
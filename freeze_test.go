@@ -0,0 +1,64 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"encoding/json"
+	"syscall/js"
+	"testing"
+)
+
+type testShared struct {
+	obj js.Value
+}
+
+func (s testShared) JSValue() js.Value {
+	return s.obj
+}
+
+func TestToJSFrozen(t *testing.T) {
+	type inner struct {
+		Tags []string
+	}
+	type outer struct {
+		Inner   inner
+		Counts  map[string]int
+		Raw     json.RawMessage
+		Value   js.Value
+		Wrapped testShared
+	}
+
+	value := js.Global().Get("Object").New()
+	wrapped := js.Global().Get("Object").New()
+	result, err := ToJSFrozenErr(outer{
+		Inner:   inner{Tags: []string{"a"}},
+		Counts:  map[string]int{"a": 1},
+		Raw:     json.RawMessage(`{"nested":[1]}`),
+		Value:   value,
+		Wrapped: testShared{obj: wrapped},
+	})
+	if err != nil {
+		t.Fatalf("ToJSFrozenErr() error = %v", err)
+	}
+
+	isFrozen := js.Global().Get("Object").Get("isFrozen")
+	for _, tt := range []struct {
+		name   string
+		value  js.Value
+		frozen bool
+	}{
+		{"result", result, true},
+		{"struct", result.Get("Inner"), true},
+		{"slice", result.Get("Inner").Get("Tags"), true},
+		{"map", result.Get("Counts"), true},
+		{"raw JSON", result.Get("Raw"), true},
+		{"nested raw JSON", result.Get("Raw").Get("nested"), true},
+		{"js.Value", value, false},
+		{"Wrapper", wrapped, false},
+	} {
+		if got := isFrozen.Invoke(tt.value).Bool(); got != tt.frozen {
+			t.Errorf("%s: frozen = %t, want %t", tt.name, got, tt.frozen)
+		}
+	}
+}
@@ -4,14 +4,24 @@
 package gowasm
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
 	"syscall/js"
 )
 
-// ErrInvalidArgumentType is returned when a generated Go function wrapper receives invalid argument types from JS.
+// ErrInvalidArgumentType is matched by the ArgumentError thrown when a generated Go function wrapper receives invalid
+// arguments from JS.
 var ErrInvalidArgumentType = errors.New("invalid argument passed into Go function")
 
+// ErrNotOK is matched by the error thrown by the JS functions created by ToJSFuncOkThrows when the Go function returns
+// a false ok value.
+var ErrNotOK = errors.New("false ok value returned by Go function")
+
 var errorType = reflect.TypeOf((*error)(nil)).Elem()
 
 type goThrowable struct {
@@ -21,75 +31,330 @@ type goThrowable struct {
 
 // toJSFunc takes a reflect.Value of a Go function and converts it to a JS function that:
 // Errors if the parameter types do not conform to the Go function signature,
-// Throws an error if the last returned value is an error and is non-nil,
-// Return an array if there's multiple non-error return values.
-func toJSFunc(x reflect.Value) js.Value {
+// Decodes each argument into its parameter with FromJSValue, throwing an ArgumentError if it cannot,
+// Passes the context set with SetFuncContext as a leading context.Context parameter,
+// Passes "this" as the first parameter if it is a js.Value, or the second one after a context.Context,
+// Passes the trailing arguments of a variadic Go function as its variadic parameter,
+// Throws an error if the last returned value is an error and is non-nil, leaving it out otherwise,
+// Returns undefined if there's no non-error return value, and an array if there's multiple of them.
+// For a method bound to its receiver, the first parameter is the first one declared after the receiver.
+// A panic inside the Go function is recovered and thrown in JS as an error instead of crashing the WASM instance.
+// The returned values are converted with the config of the encoder, and the created js.Func is tracked by its
+// Converter if it has one.
+// The function is named name in the errors thrown when its arguments do not conform.
+func (e *encoder) toJSFunc(x reflect.Value, name string) js.Value {
+	return e.toJSFuncOf(x, name, false)
+}
+
+// toJSFuncOf is toJSFunc, except that if okThrows is true, the last return value of x is a bool that is thrown as
+// ErrNotOK if it is false and dropped otherwise.
+func (e *encoder) toJSFuncOf(x reflect.Value, name string, okThrows bool) js.Value {
 	funcType := x.Type()
-	var hasError bool
-	if funcType.NumOut() != 0 {
-		hasError = funcType.Out(funcType.NumOut()-1) == errorType
-	}
+	hasError := returnsError(funcType)
+
+	return funcWrapper.Invoke(e.funcOf(func(this js.Value, args []js.Value) (result interface{}) {
+		defer func() {
+			if r := recover(); r != nil {
+				result = ToJSValue(goThrowable{
+					Error: NewError(recoveredError(r)),
+				})
+			}
+		}()
 
-	return funcWrapper.Invoke(js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		in, err := conformJSValueToType(funcType, this, args)
+		in, err := conformJSValueToType(e.config, funcType, name, this, args)
 		if err != nil {
 			return ToJSValue(goThrowable{
 				Error: NewError(err),
 			})
 		}
 
-		out := x.Call(in)
-
-		if !hasError {
-			return ToJSValue(goThrowable{
-				Result: returnValue(out),
-			})
+		var out js.Value
+		if okThrows {
+			out, err = e.derive().callOKFunc(x, name, in)
+		} else {
+			out, err = e.derive().callFunc(x, hasError, in)
 		}
-
-		lastParam := out[len(out)-1]
-		if !lastParam.IsNil() {
+		if err != nil {
 			return ToJSValue(goThrowable{
-				Error: NewError(lastParam.Interface().(error)),
+				Error: NewError(err),
 			})
 		}
 		return ToJSValue(goThrowable{
-			Result: returnValue(out[:len(out)-1]),
+			Result: out,
 		})
 	}))
 }
 
-var jsValueType = reflect.TypeOf(js.Value{})
+// defineLazyMethods attaches the provided methods of receiver to obj like structToJSObject does, except that the
+// function of each method is only created the first time JS reads its property. Each property is defined with
+// Object.defineProperty as a getter bound to its method name from a single js.Func, which replaces the property with
+// the function of the method.
+func (e *encoder) defineLazyMethods(obj js.Value, receiver reflect.Value, methods []jsMethod) error {
+	defineProperty, err := constructorProperty("Object", "defineProperty", js.TypeFunction)
+	if err != nil {
+		return err
+	}
+
+	indexes := make(map[string]int, len(methods))
+	for _, method := range methods {
+		indexes[method.name] = method.index
+	}
+
+	derived := e.derive()
+	resolve := e.funcOf(func(this js.Value, args []js.Value) interface{} {
+		name := args[0].String()
+		index := indexes[name]
+		method := derived.toJSFunc(receiver.Method(index), methodName(receiver, index))
+		defineProperty.Invoke(obj, name, js.ValueOf(map[string]interface{}{
+			"configurable": true,
+			"enumerable":   true,
+			"writable":     true,
+			"value":        method,
+		}))
+		return method
+	})
+	for _, method := range methods {
+		defineProperty.Invoke(obj, method.name, js.ValueOf(map[string]interface{}{
+			"configurable": true,
+			"enumerable":   true,
+			"get":          resolve.Call("bind", obj, method.name),
+		}))
+	}
+	return nil
+}
+
+// AsyncFunc converts the provided Go function into a JS function returning a Promise, so that JS can await Go functions
+// that block without freezing the event loop.
+// The Go function is called in its own goroutine and the Promise is fulfilled with its return values, converted as
+// they would be by the functions that ToJSValue creates. The Promise is rejected if the arguments do not conform to the
+// Go function's parameters, if the Go function panics or if its last return value is a non-nil error.
+//
+// Like with ToJSValue, the created js.Func is never released. Use Converter.AsyncFunc for functions that are discarded
+// at some point.
+//
+// It panics if fn is not a function.
+func AsyncFunc(fn interface{}) js.Value {
+	e := encoder{config: currentConfig()}
+	return e.asyncFunc(fn)
+}
+
+// asyncFunc is AsyncFunc with the config and the Converter of the encoder.
+func (e *encoder) asyncFunc(fn interface{}) js.Value {
+	x := reflect.ValueOf(fn)
+	if x.Kind() != reflect.Func {
+		panic(fmt.Sprintf("AsyncFunc requires a function, got %T", fn))
+	}
+
+	funcType := x.Type()
+	hasError := returnsError(funcType)
+	name := funcName(x)
+
+	return e.funcOf(func(this js.Value, args []js.Value) interface{} {
+		in, err := conformJSValueToType(e.config, funcType, name, this, args)
+
+		return NewPromise(func() (result interface{}, resultErr error) {
+			if err != nil {
+				return nil, err
+			}
+
+			defer func() {
+				if r := recover(); r != nil {
+					resultErr = recoveredError(r)
+				}
+			}()
+
+			return e.derive().callFunc(x, hasError, in)
+		}).JSValue()
+	}).Value
+}
+
+// ToJSFuncOkThrows converts the provided Go function like ToJSValue does, except for the handling of its last return
+// value, which must be a bool in the style of a (value, ok) result. The JS function throws an error matching ErrNotOK
+// when that bool is false, and returns the other return values without it when it is true: undefined if there are
+// none, the value itself if there is one, or an array of them otherwise.
+//
+// This has to be chosen per function. By default, ToJSValue treats a trailing bool like any other return value, packing
+// it with the others into the returned array.
+//
+// It panics if fn is not a function whose last return value is a bool.
+func ToJSFuncOkThrows(fn interface{}) js.Value {
+	x := reflect.ValueOf(fn)
+	if x.Kind() != reflect.Func || x.Type().NumOut() == 0 || x.Type().Out(x.Type().NumOut()-1).Kind() != reflect.Bool {
+		panic(fmt.Sprintf("ToJSFuncOkThrows requires a function whose last return value is a bool, got %T", fn))
+	}
+
+	e := encoder{config: currentConfig()}
+	return e.toJSFuncOf(x, funcName(x), true)
+}
+
+// returnsError reports whether the last return value of the provided function type is an error.
+func returnsError(funcType reflect.Type) bool {
+	return funcType.NumOut() != 0 && funcType.Out(funcType.NumOut()-1) == errorType
+}
+
+// callFunc calls the Go function x, returning its return values as converted by returnValue.
+// If hasError is true, the last return value is returned as the error instead if it is non-nil.
+func (e *encoder) callFunc(x reflect.Value, hasError bool, in []reflect.Value) (js.Value, error) {
+	out := x.Call(in)
+	if !hasError {
+		return e.returnValue(out)
+	}
+
+	lastParam := out[len(out)-1]
+	if !lastParam.IsNil() {
+		return js.Value{}, lastParam.Interface().(error)
+	}
+	return e.returnValue(out[:len(out)-1])
+}
+
+// callOKFunc calls the Go function x named name, returning its return values but the last one as converted by
+// returnValue, or an error matching ErrNotOK if the last one is false.
+func (e *encoder) callOKFunc(x reflect.Value, name string, in []reflect.Value) (js.Value, error) {
+	out := x.Call(in)
+	if !out[len(out)-1].Bool() {
+		return js.Value{}, fmt.Errorf("%w %s", ErrNotOK, name)
+	}
+	return e.returnValue(out[:len(out)-1])
+}
+
+// recoveredError turns a value recovered from a panic into an error.
+func recoveredError(r interface{}) error {
+	if err, ok := r.(error); ok {
+		return fmt.Errorf("panic: %w", err)
+	}
+	return fmt.Errorf("panic: %v", r)
+}
+
+var (
+	jsValueType = reflect.TypeOf(js.Value{})
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
 
-// conformJSValueToType attempts to convert the provided JS values to reflect.Values that match the
-// types expected for the parameters of funcType.
-func conformJSValueToType(funcType reflect.Type, this js.Value, values []js.Value) ([]reflect.Value, error) {
-	if funcType.NumIn() == 0 {
-		if len(values) != 0 {
-			return nil, ErrInvalidArgumentType
+// ArgumentError is thrown in JS when a Go function converted by ToJSValue or AsyncFunc is called with arguments that do
+// not conform to its parameters. It matches ErrInvalidArgumentType with errors.Is.
+type ArgumentError struct {
+	// Func names the Go function, such as "main.Add" or "*main.Cart.Checkout".
+	Func string
+	// Expected is the number of arguments the function expects, or the minimum number of arguments if Variadic.
+	Expected int
+	Variadic bool
+	// Received is the number of arguments the function was called with.
+	Received int
+
+	// Index is the index of the argument that could not be converted into Type, with the reason in Err. Err is nil if
+	// the function was called with the wrong number of arguments.
+	Index int
+	Type  reflect.Type
+	Err   error
+}
+
+// Error implements error.
+func (e ArgumentError) Error() string {
+	if e.Err == nil {
+		expected := strconv.Itoa(e.Expected)
+		if e.Variadic {
+			expected = "at least " + expected
 		}
-		return []reflect.Value{}, nil
+		return fmt.Sprintf("%v %s: expected %s arguments, got %d", ErrInvalidArgumentType, e.Func, expected, e.Received)
 	}
+	return fmt.Sprintf("%v %s: argument %d cannot be converted into %v: %v", ErrInvalidArgumentType, e.Func, e.Index,
+		e.Type, e.Err)
+}
 
-	if funcType.In(0) == jsValueType {
-		// If the first parameter is a js.Value, it is assumed to be the value of `this`.
-		values = append([]js.Value{this}, values...)
+// Is reports whether target is ErrInvalidArgumentType.
+func (e ArgumentError) Is(target error) bool {
+	return target == ErrInvalidArgumentType
+}
+
+// Unwrap returns the reason the argument could not be converted, if any.
+func (e ArgumentError) Unwrap() error {
+	return e.Err
+}
+
+// funcName returns the name of the provided Go function for ArgumentError.
+func funcName(x reflect.Value) string {
+	if f := runtime.FuncForPC(x.Pointer()); f != nil {
+		return strings.TrimSuffix(f.Name(), "-fm")
 	}
+	return x.Type().String()
+}
 
-	if funcType.IsVariadic() && funcType.NumIn()-1 > len(values) {
-		return nil, ErrInvalidArgumentType
+// thisKey is the context key of the "this" value of a call from JS.
+type thisKey struct{}
+
+// ThisFromContext returns the "this" value that JS called a Go function with, for the functions converted by ToJSValue
+// and AsyncFunc whose first parameter is a context.Context, and reports whether ctx is one passed to such a function.
+//
+// It gives access to "this" without declaring a js.Value parameter after the context, which is the other way to
+// receive it, so that the parameters following the context all receive JS arguments:
+//
+//	func(ctx context.Context, name string) {
+//		this, _ := gowasm.ThisFromContext(ctx)
+//		this.Set("name", name)
+//	}
+func ThisFromContext(ctx context.Context) (js.Value, bool) {
+	this, ok := ctx.Value(thisKey{}).(js.Value)
+	return this, ok
+}
+
+// conformJSValueToType attempts to convert the provided JS values to reflect.Values that match the types expected for
+// the parameters of funcType, decoding them with cfg, and returns an ArgumentError for the function named name
+// otherwise.
+// A leading context.Context parameter is passed the context of cfg, carrying this for ThisFromContext, instead of a JS
+// value, and a js.Value parameter that is first or follows it is passed this. For a method value, funcType does not
+// include the receiver, so the parameters are counted from the first one declared after it.
+func conformJSValueToType(cfg config, funcType reflect.Type, name string, this js.Value,
+	values []js.Value) ([]reflect.Value, error) {
+	var in []reflect.Value
+	numIn := funcType.NumIn()
+	if numIn != 0 && funcType.In(0) == contextType {
+		ctx := context.WithValue(cfg.funcContext(), thisKey{}, this)
+		in = append(in, reflect.ValueOf(&ctx).Elem())
 	}
+	offset := len(in)
 
-	if !funcType.IsVariadic() && funcType.NumIn() != len(values) {
-		return nil, ErrInvalidArgumentType
+	// If the first parameter is a js.Value, it is assumed to be the value of `this`.
+	if numIn != offset && funcType.In(offset) == jsValueType {
+		in = append(in, reflect.ValueOf(this))
 	}
+	offset = len(in)
 
-	in := make([]reflect.Value, 0, len(values))
+	// The trailing values of a variadic function are converted into the element type of its last parameter, and are
+	// spread into it by reflect.Value.Call.
+	fixed := numIn - offset
+	if funcType.IsVariadic() {
+		fixed--
+	}
+	if len(values) < fixed || (!funcType.IsVariadic() && len(values) != fixed) {
+		return nil, ArgumentError{
+			Func:     name,
+			Expected: fixed,
+			Variadic: funcType.IsVariadic(),
+			Received: len(values),
+		}
+	}
+
+	d := decoder{config: cfg}
 	for i, v := range values {
-		paramType := funcType.In(i)
+		var paramType reflect.Type
+		if i < fixed {
+			paramType = funcType.In(offset + i)
+		} else {
+			paramType = funcType.In(offset + fixed).Elem()
+		}
+
 		ptrX := reflect.New(paramType).Interface()
-		err := FromJSValue(v, ptrX)
+		err := d.fromJSValue(v, ptrX)
 		if err != nil {
-			return nil, err
+			return nil, ArgumentError{
+				Func:     name,
+				Expected: fixed,
+				Variadic: funcType.IsVariadic(),
+				Received: len(values),
+				Index:    i,
+				Type:     paramType,
+				Err:      err,
+			}
 		}
 
 		in = append(in, reflect.ValueOf(ptrX).Elem())
@@ -102,12 +367,12 @@ func conformJSValueToType(funcType reflect.Type, this js.Value, values []js.Valu
 // If there are no returned values, it returns undefined.
 // If there is exactly one, it returns the JS equivalent.
 // If there is more than one, it returns an array containing the JS equivalent of every returned value.
-func returnValue(x []reflect.Value) js.Value {
+func (e *encoder) returnValue(x []reflect.Value) (js.Value, error) {
 	switch len(x) {
 	case 0:
-		return js.Undefined()
+		return js.Undefined(), nil
 	case 1:
-		return ToJSValue(x[0].Interface())
+		return e.toJSValue(x[0].Interface())
 	}
 
 	xInterface := make([]interface{}, 0, len(x))
@@ -115,5 +380,36 @@ func returnValue(x []reflect.Value) js.Value {
 		xInterface = append(xInterface, v.Interface())
 	}
 
-	return ToJSValue(xInterface)
+	return e.toJSValue(xInterface)
+}
+
+// Call calls the method of obj with the provided name, converting each Go argument into a JS value like ToJSValue
+// does, and returns the result. The arguments are converted together, so that a value passed in several of them is
+// converted only once.
+//
+// It panics when ToJSValue would on one of the arguments, or if the method throws, like js.Value.Call.
+func Call(obj js.Value, method string, args ...interface{}) js.Value {
+	return obj.Call(method, jsArgs(args)...)
+}
+
+// Invoke calls the JS function fn with the provided Go arguments, converted like the arguments of Call, and returns
+// the result.
+//
+// It panics when ToJSValue would on one of the arguments, or if the function throws, like js.Value.Invoke.
+func Invoke(fn js.Value, args ...interface{}) js.Value {
+	return fn.Invoke(jsArgs(args)...)
+}
+
+// jsArgs converts the provided Go arguments into JS values for Call and Invoke.
+func jsArgs(args []interface{}) []interface{} {
+	e := encoder{config: currentConfig(), root: reflect.TypeOf(args)}
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		value, err := e.toJSValueAt(pathSegment{index: i}, arg)
+		if err != nil {
+			panic(err)
+		}
+		converted[i] = value
+	}
+	return converted
 }
@@ -0,0 +1,446 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"syscall/js"
+	"testing"
+	"time"
+)
+
+func TestToJSValueFuncPanicThrows(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   interface{}
+		want string
+	}{
+		{"string", func() { panic("boom") }, "panic: boom"},
+		{"error", func() int { panic(errors.New("failed")) }, "panic: failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thrown, ok := catch(ToJSValue(tt.fn))
+			if !ok {
+				t.Fatal("calling the function did not throw")
+			}
+			if got := thrown.Get("message").String(); got != tt.want {
+				t.Errorf("thrown message = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToJSValueFuncKeepsWorkingAfterPanic(t *testing.T) {
+	calls := 0
+	fn := ToJSValue(func() int {
+		calls++
+		if calls == 1 {
+			panic("first call")
+		}
+		return calls
+	})
+
+	if _, ok := catch(fn); !ok {
+		t.Fatal("first call did not throw")
+	}
+	if got := fn.Invoke().Int(); got != 2 {
+		t.Errorf("second call returned %d, want 2", got)
+	}
+}
+
+func TestToJSValueWithFuncArguments(t *testing.T) {
+	type person struct {
+		FirstName string
+	}
+	fn := ToJSValueWith(func(p person, c complex128) string {
+		return fmt.Sprint(p.FirstName, " ", c)
+	}, WithNameStrategy(CamelCase), WithComplexFormat(ComplexFormat{RealName: "re", ImagName: "im"}))
+
+	p := jsFunc(`return {firstName: "Ann"}`).Invoke()
+	c := jsFunc(`return {re: 1, im: 2}`).Invoke()
+	if got := fn.Invoke(p, c).String(); got != "Ann (1+2i)" {
+		t.Errorf("fn() = %q, want %q", got, "Ann (1+2i)")
+	}
+}
+
+func TestToJSValueWithLenientArrays(t *testing.T) {
+	sum := func(v [3]int) int {
+		return v[0] + v[1] + v[2]
+	}
+	short := jsFunc(`return [1, 2]`).Invoke()
+
+	if _, ok := catch(ToJSValue(sum), short); !ok {
+		t.Errorf("sum([1, 2]) did not throw, want an InvalidArrayError")
+	}
+	if got := ToJSValueWith(sum, WithLenientArrays(true)).Invoke(short).Int(); got != 3 {
+		t.Errorf("lenient sum([1, 2]) = %d, want 3", got)
+	}
+}
+
+type testThisCounter struct {
+	Count int
+}
+
+func (c *testThisCounter) Add(this js.Value, n int) int {
+	c.Count += n
+	this.Set("last", n)
+	return c.Count
+}
+
+func TestToJSValueMethodThis(t *testing.T) {
+	obj := ToJSValue(&testThisCounter{Count: 1})
+	if got := obj.Call("Add", 2).Int(); got != 3 {
+		t.Errorf("Add(2) = %d, want 3", got)
+	}
+	if got := obj.Get("last").Int(); got != 2 {
+		t.Errorf("this.last = %d, want 2", got)
+	}
+
+	other := js.Global().Get("Object").New()
+	obj.Get("Add").Call("call", other, 4)
+	if got := other.Get("last").Int(); got != 4 {
+		t.Errorf("this.last after calling with another this = %d, want 4", got)
+	}
+}
+
+func TestToJSValueFuncThis(t *testing.T) {
+	obj := js.Global().Get("Object").New()
+	obj.Set("withValue", ToJSValue(func(this js.Value, name string) {
+		this.Set("name", name)
+	}))
+	obj.Set("withContext", ToJSValue(func(ctx context.Context, name string) {
+		this, ok := ThisFromContext(ctx)
+		if !ok {
+			panic("no this in the context")
+		}
+		this.Set("fromContext", name)
+	}))
+
+	obj.Call("withValue", "a")
+	obj.Call("withContext", "b")
+	if got := obj.Get("name").String(); got != "a" {
+		t.Errorf("this.name = %q, want a", got)
+	}
+	if got := obj.Get("fromContext").String(); got != "b" {
+		t.Errorf("this.fromContext = %q, want b", got)
+	}
+}
+
+func TestToJSValueFuncReturnShapes(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   interface{}
+		want string
+	}{
+		{"no return value", func() {}, "undefined"},
+		{"nil error", func() error { return nil }, "undefined"},
+		{"one value", func() int { return 1 }, "1"},
+		{"value and nil error", func() (string, error) { return "a", nil }, `"a"`},
+		{"several values", func() (int, string) { return 1, "a" }, `[1,"a"]`},
+		{"several values and nil error", func() (int, bool, error) { return 1, true, nil }, "[1,true]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ToJSValue(tt.fn).Invoke()
+			got := "undefined"
+			if !result.IsUndefined() {
+				got = jsonString(t, result)
+			}
+			if got != tt.want {
+				t.Errorf("fn() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToJSValueFuncThrowsError(t *testing.T) {
+	for _, fn := range []interface{}{
+		func() error { return errors.New("failed") },
+		func() (int, error) { return 0, errors.New("failed") },
+	} {
+		thrown, ok := catch(ToJSValue(fn))
+		if !ok {
+			t.Errorf("%T did not throw", fn)
+			continue
+		}
+		if got := thrown.Get("message").String(); got != "failed" {
+			t.Errorf("%T threw %q, want failed", fn, got)
+		}
+	}
+}
+
+func TestToJSValueFuncStructArgument(t *testing.T) {
+	type person struct {
+		Name string `wasm:"name"`
+		Age  int    `wasm:"age"`
+	}
+	fn := ToJSValue(func(p person) string {
+		return fmt.Sprintf("%s is %d", p.Name, p.Age)
+	})
+
+	arg := jsFunc(`return {name: "x", age: 3}`).Invoke()
+	if got := fn.Invoke(arg).String(); got != "x is 3" {
+		t.Errorf("fn({name: \"x\", age: 3}) = %q, want %q", got, "x is 3")
+	}
+
+	thrown, ok := catch(fn, "not an object")
+	if !ok {
+		t.Fatal("fn(\"not an object\") did not throw")
+	}
+	if got := thrown.Get("message").String(); !strings.HasPrefix(got, ErrInvalidArgumentType.Error()) {
+		t.Errorf("fn(\"not an object\") threw %q, want an ArgumentError", got)
+	}
+}
+
+func TestToJSValueLazyMethods(t *testing.T) {
+	obj := ToJSValueWith(&testThisCounter{Count: 1}, WithLazyMethods(true))
+	descriptor := js.Global().Get("Object").Call("getOwnPropertyDescriptor", obj, "Add")
+	if descriptor.Get("get").Type() != js.TypeFunction {
+		t.Fatalf("Add descriptor = %s, want a getter", jsonString(t, descriptor))
+	}
+
+	if got := obj.Call("Add", 2).Int(); got != 3 {
+		t.Errorf("Add(2) = %d, want 3", got)
+	}
+	descriptor = js.Global().Get("Object").Call("getOwnPropertyDescriptor", obj, "Add")
+	if descriptor.Get("value").Type() != js.TypeFunction || !descriptor.Get("writable").Bool() {
+		t.Errorf("Add descriptor after the first read = %v, want a writable function value", descriptor)
+	}
+	if got := obj.Call("Add", 3).Int(); got != 6 {
+		t.Errorf("Add(3) = %d, want 6", got)
+	}
+}
+
+type testMethodSets struct {
+	N int
+}
+
+func (c testMethodSets) Get() int {
+	return c.N
+}
+
+func (c *testMethodSets) Inc() {
+	c.N++
+}
+
+func TestToJSValueMethodSets(t *testing.T) {
+	c := &testMethodSets{N: 1}
+	obj := ToJSValue(c)
+	if got := jsonString(t, js.Global().Get("Object").Call("keys", obj)); got != `["N","Get","Inc"]` {
+		t.Errorf("keys of a pointer = %s, want [\"N\",\"Get\",\"Inc\"]", got)
+	}
+
+	// Both methods are bound to the same receiver, so the value method sees what the pointer method did.
+	obj.Call("Inc")
+	if got := obj.Call("Get").Int(); got != 2 {
+		t.Errorf("Get() after Inc() = %d, want 2", got)
+	}
+	if c.N != 2 {
+		t.Errorf("N = %d, want 2", c.N)
+	}
+
+	// A struct that is not addressable only has its value methods.
+	obj = ToJSValue(testMethodSets{N: 3})
+	if got := jsonString(t, js.Global().Get("Object").Call("keys", obj)); got != `["N","Get"]` {
+		t.Errorf("keys of a value = %s, want [\"N\",\"Get\"]", got)
+	}
+}
+
+func TestToJSValueVariadicFuncs(t *testing.T) {
+	fn := ToJSValue(func(sep string, parts ...int) string {
+		s := make([]string, len(parts))
+		for i, p := range parts {
+			s[i] = fmt.Sprint(p)
+		}
+		return strings.Join(s, sep)
+	})
+	tests := []struct {
+		name string
+		args []interface{}
+		want string
+	}{
+		{"no variadic arguments", []interface{}{","}, ""},
+		{"one variadic argument", []interface{}{",", 1}, "1"},
+		{"several variadic arguments", []interface{}{",", 1, 2, 3}, "1,2,3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fn.Invoke(tt.args...).String(); got != tt.want {
+				t.Errorf("fn(%v) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+
+	errorTests := []struct {
+		name string
+		args []interface{}
+		want string
+	}{
+		{"missing fixed argument", nil, "expected at least 1 arguments, got 0"},
+		{"invalid trailing argument", []interface{}{",", 1, "two"}, "argument 2 cannot be converted into int"},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.name, func(t *testing.T) {
+			thrown, ok := catch(fn, tt.args...)
+			if !ok {
+				t.Fatalf("fn(%v) did not throw", tt.args)
+			}
+			if got := thrown.Get("message").String(); !strings.Contains(got, tt.want) {
+				t.Errorf("fn(%v) threw %q, want it to contain %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+type testCart struct {
+	Items []int
+}
+
+func (c testCart) GetTotal() int {
+	total := 0
+	for _, item := range c.Items {
+		total += item
+	}
+	return total
+}
+
+func (c testCart) Secret() string {
+	return "hidden"
+}
+
+func (c testCart) Len() int {
+	return len(c.Items)
+}
+
+func (testCart) JSMethodNames() map[string]string {
+	return map[string]string{"GetTotal": "total", "Secret": ""}
+}
+
+func TestToJSValueMethodNames(t *testing.T) {
+	obj := ToJSValue(testCart{Items: []int{1, 2, 3}})
+	if got := jsonString(t, js.Global().Get("Object").Call("keys", obj)); got != `["Items","total","Len"]` {
+		t.Errorf("keys = %s, want [\"Items\",\"total\",\"Len\"]", got)
+	}
+	if got := obj.Call("total").Int(); got != 6 {
+		t.Errorf("total() = %d, want 6", got)
+	}
+	if got := obj.Call("Len").Int(); got != 3 {
+		t.Errorf("Len() = %d, want 3", got)
+	}
+}
+
+func testAdd(a, b int) int {
+	return a + b
+}
+
+func TestToJSValueArgumentErrors(t *testing.T) {
+	fn := ToJSValue(testAdd)
+	prefix := ErrInvalidArgumentType.Error() + " github.com/gilang-as/gowasm.testAdd: "
+	tests := []struct {
+		name string
+		args []interface{}
+		want string
+	}{
+		{"too few arguments", []interface{}{1}, prefix + "expected 2 arguments, got 1"},
+		{"too many arguments", []interface{}{1, 2, 3}, prefix + "expected 2 arguments, got 3"},
+		{"invalid argument", []interface{}{1, "two"}, prefix + "argument 1 cannot be converted into int"},
+	}
+	isError := jsFunc("e", "return e instanceof Error")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			thrown, ok := catch(fn, tt.args...)
+			if !ok {
+				t.Fatalf("fn(%v) did not throw", tt.args)
+			}
+			if !isError.Invoke(thrown).Bool() {
+				t.Errorf("fn(%v) threw %v, want an Error", tt.args, thrown)
+			}
+			if got := thrown.Get("message").String(); !strings.HasPrefix(got, tt.want) {
+				t.Errorf("fn(%v) threw %q, want it to start with %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToJSFuncOkThrows(t *testing.T) {
+	lookup := func(key string) (int, bool) {
+		n, ok := map[string]int{"a": 1}[key]
+		return n, ok
+	}
+	tests := []struct {
+		name      string
+		fn        interface{}
+		args      []interface{}
+		want      string
+		wantThrow bool
+	}{
+		{"ok", lookup, []interface{}{"a"}, "1", false},
+		{"not ok", lookup, []interface{}{"b"}, "", true},
+		{"only ok", func() bool { return true }, nil, "undefined", false},
+		{"several values", func() (int, string, bool) { return 1, "x", true }, nil, "1,x", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fn := ToJSFuncOkThrows(tt.fn)
+			thrown, ok := catch(fn, tt.args...)
+			if ok != tt.wantThrow {
+				t.Fatalf("fn(%v) threw %v, want a throw %t", tt.args, thrown, tt.wantThrow)
+			}
+			if ok {
+				if got := thrown.Get("message").String(); !strings.HasPrefix(got, ErrNotOK.Error()) {
+					t.Errorf("fn(%v) threw %q, want an error matching ErrNotOK", tt.args, got)
+				}
+				return
+			}
+			if got := js.Global().Call("String", fn.Invoke(tt.args...)).String(); got != tt.want {
+				t.Errorf("fn(%v) = %s, want %s", tt.args, got, tt.want)
+			}
+		})
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("ToJSFuncOkThrows() of a function not returning a bool did not panic")
+		}
+	}()
+	ToJSFuncOkThrows(func() int { return 0 })
+}
+
+func TestCallAndInvoke(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+	describe := `return [...arguments].map((x) => typeof x === "function" ? "function " + x(2) :
+		x instanceof Date ? "Date " + x.toISOString() : JSON.stringify(x)).join(" ")`
+	obj := jsFunc("describe", "return {describe: new Function(describe)}").Invoke(describe)
+	double := func(n int) int { return 2 * n }
+	at := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	p := &point{X: 1, Y: 2}
+	args := []interface{}{1, "a", p, []string{"b"}, map[string]bool{"c": true}, double, at, nil, js.ValueOf(true)}
+	want := `1 "a" {"X":1,"Y":2} ["b"] {"c":true} function 4 Date 2024-01-01T00:00:00.000Z null true`
+
+	if got := Call(obj, "describe", args...).String(); got != want {
+		t.Errorf("Call() = %s, want %s", got, want)
+	}
+	if got := Invoke(obj.Get("describe"), args...).String(); got != want {
+		t.Errorf("Invoke() = %s, want %s", got, want)
+	}
+
+	// A value passed in several arguments is converted only once.
+	same := jsFunc("a", "b", "return a === b")
+	if !Invoke(same, p, p).Bool() {
+		t.Errorf("Invoke() converted a pointer passed twice into different objects")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Invoke() with an unsupported argument did not panic")
+		}
+	}()
+	Invoke(same, map[interface{}]int{nil: 1}, 0)
+}
@@ -0,0 +1,35 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"syscall/js"
+	"testing"
+)
+
+// jsFunc creates a JS function with the provided parameter names and body, like the Function constructor.
+func jsFunc(paramsAndBody ...string) js.Value {
+	args := make([]interface{}, len(paramsAndBody))
+	for i, s := range paramsAndBody {
+		args[i] = s
+	}
+	return js.Global().Get("Function").New(args...)
+}
+
+// jsonString returns the JSON.stringify form of the provided JS value.
+func jsonString(t *testing.T, v js.Value) string {
+	t.Helper()
+	return js.Global().Get("JSON").Call("stringify", v).String()
+}
+
+// catch calls the JS function fn with the provided arguments, returning what it throws, or undefined and false if it
+// returns normally.
+func catch(fn js.Value, args ...interface{}) (thrown js.Value, ok bool) {
+	result := jsFunc("fn", "args", `try { fn(...args); } catch (e) { return {thrown: e}; } return null;`).
+		Invoke(fn, js.ValueOf(args))
+	if result.IsNull() {
+		return js.Undefined(), false
+	}
+	return result.Get("thrown"), true
+}
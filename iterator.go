@@ -0,0 +1,389 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall/js"
+)
+
+// iteratorResult is the object returned by the next method of a JS iterator.
+type iteratorResult struct {
+	Value js.Value `wasm:"value"`
+	Done  bool     `wasm:"done"`
+}
+
+// returnThis is a JS function returning its "this" value, used as the [Symbol.asyncIterator] method of iterators that
+// are their own iterable.
+var returnThis = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+	return this
+})
+
+// chanToJSAsyncIterable converts the provided channel into a JS async iterable.
+// Every call to next receives from the channel in a new goroutine and returns a Promise resolving with the converted
+// value, or resolving as done once the channel is closed.
+func (e *encoder) chanToJSAsyncIterable(x reflect.Value) (js.Value, error) {
+	asyncIterator, err := constructorProperty("Symbol", "asyncIterator", js.TypeSymbol)
+	if err != nil {
+		return js.Value{}, e.errorf(x, err)
+	}
+	reflectSet, err := Global().Expect(js.TypeFunction, "Reflect", "set")
+	if err != nil {
+		return js.Value{}, e.errorf(x, err)
+	}
+	objectConstructor, err := constructor("Object")
+	if err != nil {
+		return js.Value{}, e.errorf(x, err)
+	}
+
+	next := e.funcOf(func(this js.Value, args []js.Value) interface{} {
+		return NewPromise(func() (interface{}, error) {
+			value, ok := x.Recv()
+			if !ok {
+				return iteratorResult{Done: true}, nil
+			}
+
+			jsValue, err := e.derive().toJSValue(value.Interface())
+			if err != nil {
+				return nil, err
+			}
+			return iteratorResult{Value: jsValue}, nil
+		}).JSValue()
+	})
+
+	obj := e.record(objectConstructor.New())
+	obj.Set("next", next)
+	reflectSet.Invoke(obj, asyncIterator, returnThis)
+	return obj, nil
+}
+
+// chanToJSSendFunc converts the provided send-only channel into a JS function sending its argument to the channel.
+// The argument is converted into the element type of the channel like the argument of a function converted by
+// toJSFunc. The values are queued and sent in order by a goroutine, started whenever the queue was empty, so that JS
+// never blocks on a send. A send panicking because the channel was closed drops the queued values.
+func (e *encoder) chanToJSSendFunc(x reflect.Value) js.Value {
+	var (
+		mu      sync.Mutex
+		queue   []reflect.Value
+		sending bool
+	)
+
+	send := func() {
+		defer func() {
+			if r := recover(); r != nil {
+				mu.Lock()
+				queue, sending = nil, false
+				mu.Unlock()
+			}
+		}()
+
+		for {
+			mu.Lock()
+			if len(queue) == 0 {
+				sending = false
+				mu.Unlock()
+				return
+			}
+			value := queue[0]
+			queue = queue[1:]
+			mu.Unlock()
+
+			x.Send(value)
+		}
+	}
+
+	sendType := reflect.FuncOf([]reflect.Type{x.Type().Elem()}, nil, false)
+	name := "send to " + x.Type().String()
+	return e.toJSFunc(reflect.MakeFunc(sendType, func(args []reflect.Value) []reflect.Value {
+		mu.Lock()
+		defer mu.Unlock()
+
+		queue = append(queue, args[0])
+		if !sending {
+			sending = true
+			go send()
+		}
+		return nil
+	}), name)
+}
+
+// isSeq reports whether the provided function type is an instance of iter.Seq or iter.Seq2, which are recognized by
+// their name and shape as package iter requires Go 1.23.
+func isSeq(t reflect.Type) bool {
+	if t.PkgPath() != "iter" || !strings.HasPrefix(t.Name(), "Seq") {
+		return false
+	}
+	if t.NumIn() != 1 || t.NumOut() != 0 {
+		return false
+	}
+
+	yield := t.In(0)
+	return yield.Kind() == reflect.Func && (yield.NumIn() == 1 || yield.NumIn() == 2) &&
+		yield.NumOut() == 1 && yield.Out(0).Kind() == reflect.Bool
+}
+
+// seqToJSIterable converts the provided iter.Seq or iter.Seq2 into a JS iterable. Every iteration calls the sequence
+// anew, yielding its values converted into JS values, or [key, value] arrays for an iter.Seq2.
+// A nil sequence is converted into null.
+//
+// The sequence runs in its own goroutine, which is paused at each yield until JS asks for the next value, and is
+// stopped when JS breaks out of the iteration. An iterator that JS abandons without exhausting it or calling its return
+// method keeps its goroutine paused forever. Every iteration creates the functions of its iterator, which a Converter
+// tracks like any other.
+func (e *encoder) seqToJSIterable(x reflect.Value) (js.Value, error) {
+	if x.IsNil() {
+		return js.Null(), nil
+	}
+
+	iterator, err := constructorProperty("Symbol", "iterator", js.TypeSymbol)
+	if err != nil {
+		return js.Value{}, e.errorf(x, err)
+	}
+	reflectSet, err := Global().Expect(js.TypeFunction, "Reflect", "set")
+	if err != nil {
+		return js.Value{}, e.errorf(x, err)
+	}
+	objectConstructor, err := constructor("Object")
+	if err != nil {
+		return js.Value{}, e.errorf(x, err)
+	}
+
+	newIterator := e.toJSFunc(reflect.ValueOf(func() js.Value {
+		p := &seqPull{seq: x, encoder: e.derive()}
+
+		it := objectConstructor.New()
+		it.Set("next", e.toJSFunc(reflect.ValueOf(p.next), "next"))
+		it.Set("return", e.toJSFunc(reflect.ValueOf(p.stop), "return"))
+		return it
+	}), "[Symbol.iterator]")
+
+	obj := e.record(objectConstructor.New())
+	reflectSet.Invoke(obj, iterator, newIterator)
+	return obj, nil
+}
+
+// seqPull pulls the values of an iter.Seq or iter.Seq2 one at a time for a JS iterator.
+type seqPull struct {
+	seq     reflect.Value
+	encoder *encoder
+
+	started bool
+	done    bool
+	values  chan []reflect.Value // Receives the arguments of every yield, and is closed once the sequence returns.
+	resume  chan bool            // Sends the result of the pending yield.
+	panic   interface{}          // Recovered from the sequence, set before values is closed.
+}
+
+// next resumes the sequence until it yields its next value or returns.
+func (p *seqPull) next() (iteratorResult, error) {
+	if p.done {
+		return iteratorResult{Done: true}, nil
+	}
+
+	if !p.started {
+		p.started = true
+		p.values = make(chan []reflect.Value)
+		p.resume = make(chan bool)
+		go p.run()
+	} else {
+		p.resume <- true
+	}
+
+	args, ok := <-p.values
+	if !ok {
+		p.done = true
+		if p.panic != nil {
+			return iteratorResult{}, recoveredError(p.panic)
+		}
+		return iteratorResult{Done: true}, nil
+	}
+
+	var value interface{} = interfaceOf(args[0])
+	if len(args) == 2 {
+		value = []interface{}{value, interfaceOf(args[1])}
+	}
+	jsValue, err := p.encoder.derive().toJSValue(value)
+	if err != nil {
+		p.stop()
+		return iteratorResult{}, err
+	}
+	return iteratorResult{Value: jsValue}, nil
+}
+
+// stop makes the pending yield return false and waits for the sequence to return.
+func (p *seqPull) stop() iteratorResult {
+	if p.started && !p.done {
+		p.resume <- false
+		for range p.values {
+		}
+	}
+	p.done = true
+	return iteratorResult{Done: true}
+}
+
+// run calls the sequence with a yield function handing its arguments to next.
+func (p *seqPull) run() {
+	defer close(p.values)
+	defer func() {
+		p.panic = recover()
+	}()
+
+	yieldType := p.seq.Type().In(0)
+	yield := reflect.MakeFunc(yieldType, func(args []reflect.Value) []reflect.Value {
+		p.values <- args
+		return []reflect.Value{reflect.ValueOf(<-p.resume)}
+	})
+	p.seq.Call([]reflect.Value{yield})
+}
+
+// NewEmitter converts the provided channel, which must be one that can be received from, into a JS object with a
+// subscribe(onValue, onClose) method, for pushing a Go stream of events into JS callbacks instead of having JS pull
+// them like the async iterable converted by ToJSValue.
+//
+// Once subscribed to for the first time, the emitter receives from the channel in a goroutine and calls every onValue
+// callback subscribed by then with each value, converted like ToJSValue does. When the channel is closed, the optional
+// onClose callbacks are called without arguments. If a value cannot be converted, the emitter stops receiving and
+// calls them with the JS Error instead. Exceptions thrown by the callbacks are ignored.
+//
+// subscribe returns an unsubscribe function removing the callbacks and releasing its own js.Func, so subscribers come
+// and go without leaking. As it is released by its first call, calling it again only logs an error in the console.
+// Subscribing once the channel is closed calls onClose right away.
+//
+// It panics if ch is not a channel that can be received from.
+func NewEmitter(ch interface{}) js.Value {
+	x := reflect.ValueOf(ch)
+	if x.Kind() != reflect.Chan || x.Type().ChanDir()&reflect.RecvDir == 0 {
+		panic(fmt.Sprintf("NewEmitter requires a channel that can be received from, got %T", ch))
+	}
+
+	objectConstructor, err := constructor("Object")
+	if err != nil {
+		panic(err)
+	}
+
+	em := &emitter{
+		ch:          x,
+		encoder:     encoder{config: currentConfig()},
+		subscribers: make(map[int]emitterSubscriber),
+	}
+	obj := objectConstructor.New()
+	obj.Set("subscribe", em.encoder.toJSFunc(reflect.ValueOf(em.subscribe), "subscribe"))
+	return obj
+}
+
+// emitter calls the callbacks subscribed to it with the values received from its channel.
+type emitter struct {
+	ch      reflect.Value
+	encoder encoder
+
+	mu          sync.Mutex
+	started     bool
+	closed      bool
+	closeArgs   []interface{} // Arguments of the onClose callbacks once closed.
+	nextID      int
+	subscribers map[int]emitterSubscriber
+}
+
+// emitterSubscriber holds the callbacks passed to a call to subscribe.
+type emitterSubscriber struct {
+	onValue js.Value
+	onClose js.Value // Undefined if not passed.
+}
+
+// subscribe adds the provided callbacks, the first time starting to receive from the channel, and returns the JS
+// function removing them.
+func (em *emitter) subscribe(_ js.Value, onValue js.Value, onClose ...js.Value) (js.Value, error) {
+	if onValue.Type() != js.TypeFunction {
+		return js.Value{}, fmt.Errorf("subscribe requires an onValue function, got a JS %v", onValue.Type())
+	}
+	sub := emitterSubscriber{onValue: onValue, onClose: js.Undefined()}
+	if len(onClose) != 0 && onClose[0].Type() == js.TypeFunction {
+		sub.onClose = onClose[0]
+	}
+
+	em.mu.Lock()
+	id := em.nextID
+	em.nextID++
+	closed, closeArgs := em.closed, em.closeArgs
+	if !closed {
+		em.subscribers[id] = sub
+		if !em.started {
+			em.started = true
+			go em.run()
+		}
+	}
+	em.mu.Unlock()
+
+	if closed {
+		sub.close(closeArgs)
+	}
+
+	var unsubscribe js.Func
+	unsubscribe = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		em.mu.Lock()
+		delete(em.subscribers, id)
+		em.mu.Unlock()
+		unsubscribe.Release()
+		return nil
+	})
+	return unsubscribe.Value, nil
+}
+
+// run receives from the channel and calls the subscribers with every value until the channel is closed.
+func (em *emitter) run() {
+	for {
+		value, ok := em.ch.Recv()
+
+		var closeArgs []interface{}
+		var jsValue js.Value
+		if ok {
+			var err error
+			jsValue, err = em.encoder.derive().toJSValue(value.Interface())
+			if err != nil {
+				closeArgs = []interface{}{NewError(err)}
+			}
+		}
+
+		em.mu.Lock()
+		subscribers := make([]emitterSubscriber, 0, len(em.subscribers))
+		for _, sub := range em.subscribers {
+			subscribers = append(subscribers, sub)
+		}
+		done := !ok || closeArgs != nil
+		if done {
+			em.closed, em.closeArgs = true, closeArgs
+			em.subscribers = nil
+		}
+		em.mu.Unlock()
+
+		for _, sub := range subscribers {
+			if done {
+				sub.close(closeArgs)
+			} else {
+				sub.call(sub.onValue, jsValue)
+			}
+		}
+		if done {
+			return
+		}
+	}
+}
+
+// close calls the onClose callback of the subscriber, if any, with the provided arguments.
+func (sub emitterSubscriber) close(args []interface{}) {
+	if !sub.onClose.IsUndefined() {
+		sub.call(sub.onClose, args...)
+	}
+}
+
+// call invokes the provided callback, ignoring the exception it throws if any.
+func (sub emitterSubscriber) call(callback js.Value, args ...interface{}) {
+	defer func() {
+		_ = recover()
+	}()
+	callback.Invoke(args...)
+}
@@ -0,0 +1,59 @@
+//go:build js && wasm && go1.23
+// +build js,wasm,go1.23
+
+package gowasm
+
+import (
+	"iter"
+	"testing"
+)
+
+func TestToJSValueSeqs(t *testing.T) {
+	seq := iter.Seq[int](func(yield func(int) bool) {
+		for i := 1; i <= 3; i++ {
+			if !yield(i) {
+				return
+			}
+		}
+	})
+	seq2 := iter.Seq2[string, int](func(yield func(string, int) bool) {
+		_ = yield("a", 1) && yield("b", 2)
+	})
+	tests := []struct {
+		name string
+		x    interface{}
+		want string
+	}{
+		{"iter.Seq", seq, `[1,2,3]`},
+		{"iter.Seq2", seq2, `[["a",1],["b",2]]`},
+	}
+	collect := jsFunc("it", "return [...it]")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := ToJSValue(tt.x)
+			if got := jsonString(t, collect.Invoke(value)); got != tt.want {
+				t.Errorf("[...ToJSValue()] = %s, want %s", got, tt.want)
+			}
+			// Every iteration calls the sequence anew.
+			if got := jsonString(t, collect.Invoke(value)); got != tt.want {
+				t.Errorf("second [...ToJSValue()] = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	stopped := false
+	first := jsFunc("it", "for (const x of it) return x")
+	value := ToJSValue(iter.Seq[int](func(yield func(int) bool) {
+		stopped = !yield(1)
+	}))
+	if got := first.Invoke(value).Int(); got != 1 {
+		t.Errorf("first value = %d, want 1", got)
+	}
+	if !stopped {
+		t.Errorf("breaking out of the iteration did not stop the sequence")
+	}
+
+	if got := ToJSValue(iter.Seq[int](nil)); !got.IsNull() {
+		t.Errorf("ToJSValue(nil iter.Seq) = %v, want null", got)
+	}
+}
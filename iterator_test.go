@@ -0,0 +1,97 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"syscall/js"
+	"testing"
+	"time"
+)
+
+func TestToJSValueChanAsyncIterable(t *testing.T) {
+	ch := make(chan int, 3)
+	ch <- 1
+	ch <- 2
+	ch <- 3
+	close(ch)
+
+	collect := jsFunc("it", `return (async () => {
+		const values = [];
+		for await (const x of it) values.push(x);
+		return values;
+	})();`)
+	values, err := Await(collect.Invoke(ToJSValue(ch)))
+	if err != nil {
+		t.Fatalf("iterating the channel failed: %v", err)
+	}
+	if got := jsonString(t, values); got != "[1,2,3]" {
+		t.Errorf("values = %s, want [1,2,3]", got)
+	}
+}
+
+func TestToJSValueSendOnlyChan(t *testing.T) {
+	ch := make(chan int)
+	push := ToJSValue((chan<- int)(ch))
+	jsFunc("push", "push(1); push(2); push(3);").Invoke(push)
+
+	// The values are sent in order by a goroutine, so that pushing never blocks JS even though nothing receives yet.
+	for want := 1; want <= 3; want++ {
+		select {
+		case got := <-ch:
+			if got != want {
+				t.Errorf("received %d, want %d", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %d", want)
+		}
+	}
+
+	if _, ok := catch(push, "not an int"); !ok {
+		t.Errorf("push(\"not an int\") did not throw, want an ArgumentError")
+	}
+}
+
+func TestNewEmitter(t *testing.T) {
+	ch := make(chan int)
+	emitter := NewEmitter(ch)
+
+	closed := make(chan struct{})
+	onClose := js.FuncOf(func(js.Value, []js.Value) interface{} {
+		close(closed)
+		return nil
+	})
+	defer onClose.Release()
+
+	received := js.Global().Get("Array").New()
+	push := jsFunc("values", "return (x) => values.push(x)").Invoke(received)
+	emitter.Call("subscribe", push, onClose)
+
+	ignored := js.Global().Get("Array").New()
+	unsubscribe := emitter.Call("subscribe", jsFunc("values", "return (x) => values.push(x)").Invoke(ignored))
+	unsubscribe.Invoke()
+
+	for i := 1; i <= 3; i++ {
+		ch <- i
+	}
+	close(ch)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for onClose")
+	}
+
+	if got := jsonString(t, received); got != "[1,2,3]" {
+		t.Errorf("received %s, want [1,2,3]", got)
+	}
+	if got := ignored.Length(); got != 0 {
+		t.Errorf("unsubscribed callback received %d values, want 0", got)
+	}
+
+	// Subscribing once the channel is closed calls onClose right away.
+	late := jsFunc("return {closed: false}").Invoke()
+	emitter.Call("subscribe", jsFunc("", ""), jsFunc("late", "return () => { late.closed = true; }").Invoke(late))
+	if !late.Get("closed").Bool() {
+		t.Errorf("onClose of a subscription made after closing was not called")
+	}
+}
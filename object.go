@@ -5,6 +5,8 @@ package gowasm
 
 import (
 	"fmt"
+	"math"
+	"sync"
 	"syscall/js"
 )
 
@@ -18,10 +20,25 @@ func (e TypeMismatchError) Error() string {
 	return fmt.Sprintf("expected %v type, got %v type instead", e.Expected, e.Actual)
 }
 
+var (
+	globalOnce   sync.Once
+	cachedGlobal Object
+	globalErr    error
+)
+
+// globalObject returns the global object, which is looked up once and cached as it cannot change.
+func globalObject() (Object, error) {
+	globalOnce.Do(func() {
+		cachedGlobal, globalErr = NewObject(js.Global())
+	})
+	return cachedGlobal, globalErr
+}
+
 // Global returns the global object as a Object.
 // If the global object is not an object, it panics.
+// It is safe to call Global concurrently, and the global object is only looked up on the first call.
 func Global() Object {
-	global, err := NewObject(js.Global())
+	global, err := globalObject()
 	if err != nil {
 		panic(err)
 	}
@@ -143,3 +160,161 @@ func (o Object) String() string {
 
 	return jsonStr.String()
 }
+
+// PropertyError is returned by GetString, GetInt, GetBool and GetFloat when a property cannot be read as the requested
+// Go type.
+type PropertyError struct {
+	Key string
+	Err error
+}
+
+// Error implements error.
+func (e PropertyError) Error() string {
+	return fmt.Sprintf("property %q: %v", e.Key, e.Err)
+}
+
+// Unwrap returns the reason the property could not be read, usually a TypeMismatchError.
+func (e PropertyError) Unwrap() error {
+	return e.Err
+}
+
+// getTyped returns the property key of v after checking that it has the expected type.
+func getTyped(v js.Value, key string, expected js.Type) (js.Value, error) {
+	if isBigInt(v) {
+		return js.Value{}, PropertyError{Key: key, Err: errBigIntMismatch(js.TypeObject)}
+	}
+	if v.Type() != js.TypeObject && v.Type() != js.TypeFunction {
+		return js.Value{}, PropertyError{Key: key, Err: TypeMismatchError{Expected: js.TypeObject, Actual: v.Type()}}
+	}
+
+	value := v.Get(key)
+	if isBigInt(value) {
+		return js.Value{}, PropertyError{Key: key, Err: errBigIntMismatch(expected)}
+	}
+	if value.Type() != expected {
+		return js.Value{}, PropertyError{Key: key, Err: TypeMismatchError{Expected: expected, Actual: value.Type()}}
+	}
+	return value, nil
+}
+
+// errBigIntMismatch returns the error of finding a BigInt instead of a value of the expected type. TypeMismatchError
+// cannot be used as js.Type has no value for BigInts.
+func errBigIntMismatch(expected js.Type) error {
+	return fmt.Errorf("expected %v type, got bigint type instead", expected)
+}
+
+// GetString returns the string property key of v.
+// Unlike v.Get(key).String(), it returns a PropertyError if v is not an object or the property is missing or not a
+// string, instead of returning a placeholder such as "<number: 1>".
+func GetString(v js.Value, key string) (string, error) {
+	value, err := getTyped(v, key, js.TypeString)
+	if err != nil {
+		return "", err
+	}
+	return value.String(), nil
+}
+
+// GetInt returns the integer property key of v.
+// Unlike v.Get(key).Int(), it returns a PropertyError instead of panicking if v is not an object or the property is
+// missing or not a number, and instead of truncating if the number is not an integer.
+func GetInt(v js.Value, key string) (int, error) {
+	value, err := getTyped(v, key, js.TypeNumber)
+	if err != nil {
+		return 0, err
+	}
+
+	// float64(math.MaxInt) rounds up to 1<<63, which is out of range.
+	f := value.Float()
+	if f != math.Trunc(f) || f < math.MinInt || f >= math.MaxInt {
+		return 0, PropertyError{Key: key, Err: fmt.Errorf("%v is not an int", f)}
+	}
+	return int(f), nil
+}
+
+// GetBool returns the boolean property key of v.
+// Unlike v.Get(key).Bool(), it returns a PropertyError instead of panicking if v is not an object or the property is
+// missing or not a boolean.
+func GetBool(v js.Value, key string) (bool, error) {
+	value, err := getTyped(v, key, js.TypeBoolean)
+	if err != nil {
+		return false, err
+	}
+	return value.Bool(), nil
+}
+
+// GetFloat returns the number property key of v.
+// Unlike v.Get(key).Float(), it returns a PropertyError instead of panicking if v is not an object or the property is
+// missing or not a number.
+func GetFloat(v js.Value, key string) (float64, error) {
+	value, err := getTyped(v, key, js.TypeNumber)
+	if err != nil {
+		return 0, err
+	}
+	return value.Float(), nil
+}
+
+// ObjectEntry is an own enumerable property of a JS object, as returned by ObjectEntries.
+type ObjectEntry struct {
+	Key   string
+	Value js.Value
+}
+
+// isObjectLike reports whether the provided value is an object or a function, which can hold properties.
+func isObjectLike(v js.Value) bool {
+	return !isBigInt(v) && (v.Type() == js.TypeObject || v.Type() == js.TypeFunction)
+}
+
+// callObject calls the provided static method of the global Object on v.
+func callObject(method string, v js.Value) js.Value {
+	objectConstructor, err := constructor("Object")
+	if err != nil {
+		panic(err)
+	}
+	return objectConstructor.Call(method, v)
+}
+
+// ObjectKeys returns the names of the own enumerable properties of v, as returned by Object.keys.
+// It returns an empty slice if v is not an object.
+func ObjectKeys(v js.Value) []string {
+	if !isObjectLike(v) {
+		return []string{}
+	}
+
+	keys := callObject("keys", v)
+	result := make([]string, keys.Length())
+	for i := range result {
+		result[i] = keys.Index(i).String()
+	}
+	return result
+}
+
+// ObjectValues returns the values of the own enumerable properties of v, as returned by Object.values.
+// It returns an empty slice if v is not an object.
+func ObjectValues(v js.Value) []js.Value {
+	if !isObjectLike(v) {
+		return []js.Value{}
+	}
+
+	values := callObject("values", v)
+	result := make([]js.Value, values.Length())
+	for i := range result {
+		result[i] = values.Index(i)
+	}
+	return result
+}
+
+// ObjectEntries returns the own enumerable properties of v, as returned by Object.entries.
+// It returns an empty slice if v is not an object.
+func ObjectEntries(v js.Value) []ObjectEntry {
+	if !isObjectLike(v) {
+		return []ObjectEntry{}
+	}
+
+	entries := callObject("entries", v)
+	result := make([]ObjectEntry, entries.Length())
+	for i := range result {
+		entry := entries.Index(i)
+		result[i] = ObjectEntry{Key: entry.Index(0).String(), Value: entry.Index(1)}
+	}
+	return result
+}
@@ -0,0 +1,208 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"syscall/js"
+	"testing"
+)
+
+func TestGetters(t *testing.T) {
+	obj := jsFunc(`return {s: "a", n: 2, f: 1.5, b: true, o: {}}`).Invoke()
+	get := map[string]func(key string) (interface{}, error){
+		"GetString": func(key string) (interface{}, error) { return GetString(obj, key) },
+		"GetInt":    func(key string) (interface{}, error) { return GetInt(obj, key) },
+		"GetFloat":  func(key string) (interface{}, error) { return GetFloat(obj, key) },
+		"GetBool":   func(key string) (interface{}, error) { return GetBool(obj, key) },
+	}
+
+	tests := []struct {
+		getter   string
+		key      string
+		want     interface{}
+		wantErr  bool
+		wantType js.Type // Actual type of the TypeMismatchError.
+	}{
+		{"GetString", "s", "a", false, 0},
+		{"GetString", "n", "", true, js.TypeNumber},
+		{"GetString", "missing", "", true, js.TypeUndefined},
+		{"GetInt", "n", 2, false, 0},
+		{"GetInt", "s", 0, true, js.TypeString},
+		{"GetInt", "missing", 0, true, js.TypeUndefined},
+		{"GetFloat", "f", 1.5, false, 0},
+		{"GetFloat", "b", 0.0, true, js.TypeBoolean},
+		{"GetFloat", "missing", 0.0, true, js.TypeUndefined},
+		{"GetBool", "b", true, false, 0},
+		{"GetBool", "o", false, true, js.TypeObject},
+		{"GetBool", "missing", false, true, js.TypeUndefined},
+	}
+	for _, tt := range tests {
+		got, err := get[tt.getter](tt.key)
+		if got != tt.want {
+			t.Errorf("%s(%q) = %v, want %v", tt.getter, tt.key, got, tt.want)
+		}
+
+		var propErr PropertyError
+		var mismatch TypeMismatchError
+		switch {
+		case !tt.wantErr && err != nil:
+			t.Errorf("%s(%q) error = %v, want none", tt.getter, tt.key, err)
+		case !tt.wantErr:
+		case !errors.As(err, &propErr) || propErr.Key != tt.key:
+			t.Errorf("%s(%q) error = %v, want a PropertyError for %q", tt.getter, tt.key, err, tt.key)
+		case !errors.As(err, &mismatch) || mismatch.Actual != tt.wantType:
+			t.Errorf("%s(%q) error = %v, want a TypeMismatchError with %v", tt.getter, tt.key, err, tt.wantType)
+		}
+	}
+
+	// Properties of primitives cannot be read.
+	if _, err := GetString(js.ValueOf("a"), "length"); err == nil {
+		t.Errorf("GetString() on a string error = nil, want a PropertyError")
+	}
+}
+
+func TestGettersBigInt(t *testing.T) {
+	obj := jsFunc(`return {n: 10n}`).Invoke()
+	bigInt := jsFunc(`return 10n`).Invoke()
+
+	var propErr PropertyError
+	if _, err := GetInt(obj, "n"); !errors.As(err, &propErr) {
+		t.Errorf("GetInt() error = %v, want a PropertyError", err)
+	}
+	if _, err := GetFloat(obj, "n"); !errors.As(err, &propErr) {
+		t.Errorf("GetFloat() error = %v, want a PropertyError", err)
+	}
+	if _, err := GetString(obj, "n"); !errors.As(err, &propErr) {
+		t.Errorf("GetString() error = %v, want a PropertyError", err)
+	}
+	if _, err := GetBool(bigInt, "n"); !errors.As(err, &propErr) {
+		t.Errorf("GetBool() on a BigInt error = %v, want a PropertyError", err)
+	}
+}
+
+func TestObjectHelpers(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		wantKeys   string
+		wantValues string
+	}{
+		{"object", `({a: 1, b: "x", [Symbol("s")]: 2})`, "a,b", "1,x"},
+		{"non-enumerable property", `Object.defineProperty({a: 1}, "hidden", {value: 2})`, "a", "1"},
+		{"array", `["x", "y"]`, "0,1", "x,y"},
+		{"function", `Object.assign(() => {}, {f: 3})`, "f", "3"},
+		{"empty object", `({})`, "", ""},
+		{"string", `"abc"`, "", ""},
+		{"null", `null`, "", ""},
+		{"undefined", `undefined`, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := jsFunc("return " + tt.source).Invoke()
+
+			keys := ObjectKeys(v)
+			if got := strings.Join(keys, ","); got != tt.wantKeys {
+				t.Errorf("ObjectKeys() = %q, want %q", got, tt.wantKeys)
+			}
+
+			values := ObjectValues(v)
+			strs := make([]string, len(values))
+			for i, value := range values {
+				strs[i] = js.Global().Call("String", value).String()
+			}
+			if got := strings.Join(strs, ","); got != tt.wantValues {
+				t.Errorf("ObjectValues() = %q, want %q", got, tt.wantValues)
+			}
+
+			entries := ObjectEntries(v)
+			if len(entries) != len(keys) {
+				t.Fatalf("len(ObjectEntries()) = %d, want %d", len(entries), len(keys))
+			}
+			for i, entry := range entries {
+				if entry.Key != keys[i] || !entry.Value.Equal(values[i]) {
+					t.Errorf("ObjectEntries()[%d] = {%s %v}, want {%s %v}", i, entry.Key, entry.Value, keys[i], values[i])
+				}
+			}
+		})
+	}
+}
+
+func TestObjectHelpersBigInt(t *testing.T) {
+	bigInt := jsFunc(`return 10n`).Invoke()
+
+	if keys := ObjectKeys(bigInt); len(keys) != 0 {
+		t.Errorf("ObjectKeys() = %v, want none", keys)
+	}
+	if values := ObjectValues(bigInt); len(values) != 0 {
+		t.Errorf("ObjectValues() = %v, want none", values)
+	}
+	if entries := ObjectEntries(bigInt); len(entries) != 0 {
+		t.Errorf("ObjectEntries() = %v, want none", entries)
+	}
+}
+
+func TestObjectGetFunction(t *testing.T) {
+	// Object.Get only descends through objects, even though functions can have properties.
+	var mismatch TypeMismatchError
+	if _, err := Global().Get("Object", "entries"); !errors.As(err, &mismatch) {
+		t.Errorf("Get() error = %v, want a TypeMismatchError", err)
+	}
+}
+
+func TestGetIntRange(t *testing.T) {
+	tests := []struct {
+		source  string
+		want    int
+		wantErr bool
+	}{
+		{"1", 1, false},
+		{"-9223372036854775808", math.MinInt, false},
+		{"9223372036854774784", 9223372036854774784, false},
+		{"9223372036854775808", 0, true},
+		{"-9223372036854777856", 0, true},
+		{"1.5", 0, true},
+		{"NaN", 0, true},
+		{"Infinity", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := GetInt(jsFunc("return {n: "+tt.source+"}").Invoke(), "n")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("GetInt(%s) = %d, %v, want %d, error %t", tt.source, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestGlobalConcurrent(t *testing.T) {
+	const goroutines = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines)
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			parse, err := Global().Get("JSON", "parse")
+			if err != nil {
+				errs <- err
+				return
+			}
+			if parse.Type() != js.TypeFunction {
+				errs <- fmt.Errorf("JSON.parse is a %v", parse.Type())
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("Global().Get(\"JSON\", \"parse\") error = %v", err)
+	}
+
+	if !Global().Equal(js.Global()) {
+		t.Errorf("Global() is not js.Global()")
+	}
+}
@@ -0,0 +1,446 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// config holds the settings that change how Go values are converted into JS values.
+type config struct {
+	rfc3339Dates         bool
+	nameStrategy         NameStrategy
+	jsMaps               bool
+	nilCollectionsAsNull bool
+	ctx                  context.Context
+	includePrivate       bool
+	nonFiniteFloats      NonFiniteFloats
+	lazyMethods          bool
+	nilPointersAsNull    bool
+	maxDepth             int
+	bigFloatsAsStrings   bool
+	complexFormat        ComplexFormat
+	zeroTimesAsNull      bool
+	lenientArrays        bool
+	buffersAsBytes       bool
+	stringerEnums        bool
+	sortedMapKeys        bool
+	jsonMarshalers       bool
+	strictStructs        bool
+
+	omitNilFuncsAndInterfaces bool
+}
+
+var (
+	configMu      sync.RWMutex
+	packageConfig = config{maxDepth: DefaultMaxDepth}
+)
+
+// currentConfig returns a copy of the package-level config.
+func currentConfig() config {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return packageConfig
+}
+
+// updateConfig applies the provided change to the package-level config.
+func updateConfig(change Option) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	change(&packageConfig)
+}
+
+// Option changes how a single conversion made by ToJSValueWith converts Go values, overriding the package-level
+// setting of its Set counterpart, such as SetMaxDepth for WithMaxDepth.
+type Option func(*config)
+
+// configWith returns a copy of the package-level config with the provided options applied.
+func configWith(opts []Option) config {
+	c := currentConfig()
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// SetRFC3339Dates controls whether time.Time values are passed to the JS Date constructor as RFC 3339 strings instead
+// of epoch milliseconds. It restores the behaviour of earlier versions, which drops sub-second precision.
+// It is disabled by default.
+func SetRFC3339Dates(enabled bool) {
+	updateConfig(WithRFC3339Dates(enabled))
+}
+
+// WithRFC3339Dates is the Option equivalent of SetRFC3339Dates, for a single conversion.
+func WithRFC3339Dates(enabled bool) Option {
+	return func(c *config) {
+		c.rfc3339Dates = enabled
+	}
+}
+
+// SetZeroTimesAsNull controls whether the zero time.Time, such as an unset time.Time field, is converted into null
+// instead of a Date in year 1, including for fields with the format option. It is disabled by default.
+func SetZeroTimesAsNull(enabled bool) {
+	updateConfig(WithZeroTimesAsNull(enabled))
+}
+
+// WithZeroTimesAsNull is the Option equivalent of SetZeroTimesAsNull, for a single conversion.
+func WithZeroTimesAsNull(enabled bool) Option {
+	return func(c *config) {
+		c.zeroTimesAsNull = enabled
+	}
+}
+
+// SetNilCollectionsAsNull controls whether nil slices and nil maps are converted into null instead of an empty array,
+// typed array or object, keeping them distinguishable from empty ones. It is disabled by default.
+func SetNilCollectionsAsNull(enabled bool) {
+	updateConfig(WithNilCollectionsAsNull(enabled))
+}
+
+// WithNilCollectionsAsNull is the Option equivalent of SetNilCollectionsAsNull, for a single conversion.
+func WithNilCollectionsAsNull(enabled bool) Option {
+	return func(c *config) {
+		c.nilCollectionsAsNull = enabled
+	}
+}
+
+// SetFuncContext sets the context passed to the Go functions converted by ToJSValue and AsyncFunc whose first parameter
+// is a context.Context, which JS callers do not pass. A nil context, the default, passes context.Background().
+//
+// The functions use the context set when they were converted, so cancelling it reaches every call made from JS.
+func SetFuncContext(ctx context.Context) {
+	updateConfig(WithFuncContext(ctx))
+}
+
+// WithFuncContext is the Option equivalent of SetFuncContext, for a single conversion.
+func WithFuncContext(ctx context.Context) Option {
+	return func(c *config) {
+		c.ctx = ctx
+	}
+}
+
+// funcContext returns the context passed to Go functions taking a context.Context.
+func (c config) funcContext() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// IncludePrivate controls whether ToJSValue includes the unexported fields of structs, named like exported fields,
+// which is useful for debug views of values. It is disabled by default.
+//
+// Unexported fields are read with package unsafe, bypassing the protection the Go type system gives them: the JS
+// side gets to see internal state such as credentials or locks, which the package defining the struct never meant to
+// expose, and functions or pointers held by those fields are handed to JS as well. The fields are copied at the time of
+// the conversion, so this does not allow JS to modify them. Only enable it for values that are safe to expose in full.
+func IncludePrivate(enabled bool) {
+	updateConfig(WithPrivateFields(enabled))
+}
+
+// WithPrivateFields is the Option equivalent of IncludePrivate, for a single conversion.
+func WithPrivateFields(enabled bool) Option {
+	return func(c *config) {
+		c.includePrivate = enabled
+	}
+}
+
+// SetLazyMethods controls whether ToJSValue attaches the methods of structs as getters that create the JS function of a
+// method the first time it is read, instead of creating a js.Func for every method up front. It saves the cost of
+// wrapping methods that JS never calls, which adds up for structs with many methods converted in bulk, at the cost of
+// a getter call on the first access of each method. It is disabled by default.
+//
+// Each converted struct then holds a single js.Func resolving its methods. When converting with a Converter, the
+// functions of the methods are tracked once they are created, so Release only releases the methods read by then.
+// After Release, reading a method that was never read before no longer calls into Go.
+func SetLazyMethods(enabled bool) {
+	updateConfig(WithLazyMethods(enabled))
+}
+
+// WithLazyMethods is the Option equivalent of SetLazyMethods, for a single conversion.
+func WithLazyMethods(enabled bool) Option {
+	return func(c *config) {
+		c.lazyMethods = enabled
+	}
+}
+
+// NonFiniteFloats selects how ToJSValue converts floats that are NaN, +Inf or -Inf.
+type NonFiniteFloats int
+
+const (
+	// NonFiniteAsNumber converts them into the JS numbers NaN, Infinity and -Infinity. JSON.stringify silently turns
+	// these into null.
+	NonFiniteAsNumber NonFiniteFloats = iota
+	// NonFiniteAsString converts them into the JS strings "NaN", "Infinity" and "-Infinity".
+	NonFiniteAsString
+	// NonFiniteAsNull converts them into null.
+	NonFiniteAsNull
+)
+
+// SetNonFiniteFloats sets how ToJSValue converts floats that are NaN or an infinity, so that JS code receiving them
+// does not depend on how they happen to be serialized later. The default is NonFiniteAsNumber.
+// With another mode than NonFiniteAsNumber, slices of floats are converted into plain Arrays rather than into a
+// Float32Array or Float64Array, which can only hold numbers.
+func SetNonFiniteFloats(mode NonFiniteFloats) {
+	updateConfig(WithNonFiniteFloats(mode))
+}
+
+// WithNonFiniteFloats is the Option equivalent of SetNonFiniteFloats, for a single conversion.
+func WithNonFiniteFloats(mode NonFiniteFloats) Option {
+	return func(c *config) {
+		c.nonFiniteFloats = mode
+	}
+}
+
+// SetNilPointersAsNull controls whether nil pointers are converted into null instead of undefined, wherever they are
+// found: struct fields, array and slice elements, map values or the value passed in itself. Many JS consumers treat
+// null as a present but empty value and undefined as an absent one. For example, a []*int holding nils is converted
+// into an array holding null at their indices instead of undefined, keeping its length and indices either way.
+// It is disabled by default.
+func SetNilPointersAsNull(enabled bool) {
+	updateConfig(WithNilPointersAsNull(enabled))
+}
+
+// WithNilPointersAsNull is the Option equivalent of SetNilPointersAsNull, for a single conversion.
+func WithNilPointersAsNull(enabled bool) Option {
+	return func(c *config) {
+		c.nilPointersAsNull = enabled
+	}
+}
+
+// DefaultMaxDepth is the default limit of SetMaxDepth.
+const DefaultMaxDepth = 100
+
+// SetMaxDepth limits how deeply values nested inside the value passed to ToJSValueErr can be, counting a level for
+// every struct field, array or slice element and map value stepped into. Deeper values make it return an error wrapping
+// ErrMaxDepth, and ToJSValue panic, instead of exhausting the stack or the memory. A limit of 0 or less removes it.
+// The limit is DefaultMaxDepth by default.
+func SetMaxDepth(depth int) {
+	updateConfig(WithMaxDepth(depth))
+}
+
+// WithMaxDepth is the Option equivalent of SetMaxDepth, for a single conversion.
+func WithMaxDepth(depth int) Option {
+	return func(c *config) {
+		c.maxDepth = depth
+	}
+}
+
+// SetBigFloatsAsStrings controls whether big.Float values are converted into JS strings holding their decimal form
+// instead of the nearest JS number, for when their precision matters. It is disabled by default.
+func SetBigFloatsAsStrings(enabled bool) {
+	updateConfig(WithBigFloatsAsStrings(enabled))
+}
+
+// WithBigFloatsAsStrings is the Option equivalent of SetBigFloatsAsStrings, for a single conversion.
+func WithBigFloatsAsStrings(enabled bool) Option {
+	return func(c *config) {
+		c.bigFloatsAsStrings = enabled
+	}
+}
+
+// ComplexFormat describes how complex numbers are represented in JS.
+// Its zero value represents them as objects with a real and imag property.
+type ComplexFormat struct {
+	// RealName and ImagName are the names of the properties holding the real and imaginary parts, "real" and "imag"
+	// if empty.
+	RealName, ImagName string
+	// AsArray represents complex numbers as [real, imag] arrays instead of objects, ignoring the property names.
+	AsArray bool
+}
+
+// names returns the property names of the real and imaginary parts.
+func (f ComplexFormat) names() (realName, imagName string) {
+	realName, imagName = f.RealName, f.ImagName
+	if realName == "" {
+		realName = "real"
+	}
+	if imagName == "" {
+		imagName = "imag"
+	}
+	return realName, imagName
+}
+
+// SetComplexFormat sets how ToJSValue converts complex64 and complex128 values, and how FromJSValue expects to find
+// them, for JS libraries expecting objects such as {re, im} or [real, imag] arrays. FromJSValue decodes arrays of two
+// numbers into complex numbers whatever the format.
+// The default is the zero ComplexFormat.
+func SetComplexFormat(format ComplexFormat) {
+	updateConfig(WithComplexFormat(format))
+}
+
+// WithComplexFormat is the Option equivalent of SetComplexFormat, for a single conversion.
+func WithComplexFormat(format ComplexFormat) Option {
+	return func(c *config) {
+		c.complexFormat = format
+	}
+}
+
+// SetBuffersAsBytes controls whether ToJSValue converts a bytes.Buffer into a Uint8Array holding a copy of its unread
+// contents instead of a JS string, for buffers holding binary data. It is disabled by default.
+func SetBuffersAsBytes(enabled bool) {
+	updateConfig(WithBuffersAsBytes(enabled))
+}
+
+// WithBuffersAsBytes is the Option equivalent of SetBuffersAsBytes, for a single conversion.
+func WithBuffersAsBytes(enabled bool) Option {
+	return func(c *config) {
+		c.buffersAsBytes = enabled
+	}
+}
+
+// SetStringerEnums controls whether ToJSValue converts values of integer types implementing fmt.Stringer, such as
+// enums declared as `type Color int` with a String method, into JS strings holding their String form instead of
+// numbers. Slices of such types are then converted into arrays of strings rather than typed arrays. Types implementing
+// encoding.TextMarshaler are converted into their text form either way. It is disabled by default.
+func SetStringerEnums(enabled bool) {
+	updateConfig(WithStringerEnums(enabled))
+}
+
+// WithStringerEnums is the Option equivalent of SetStringerEnums, for a single conversion.
+func WithStringerEnums(enabled bool) Option {
+	return func(c *config) {
+		c.stringerEnums = enabled
+	}
+}
+
+// SetSortedMapKeys controls whether ToJSValue converts the entries of Go maps in the order of their keys, numerically
+// for numbers and lexicographically for strings, instead of the random iteration order of Go maps, so that the
+// converted objects and Maps are the same on every run. It is disabled by default, which is faster.
+//
+// JS objects list integer-like keys in ascending order before the other keys whatever the order they were set in, so
+// the order of string keys is only fully kept by Maps.
+func SetSortedMapKeys(enabled bool) {
+	updateConfig(WithSortedMapKeys(enabled))
+}
+
+// WithSortedMapKeys is the Option equivalent of SetSortedMapKeys, for a single conversion.
+func WithSortedMapKeys(enabled bool) Option {
+	return func(c *config) {
+		c.sortedMapKeys = enabled
+	}
+}
+
+// SetOmitNilFuncsAndInterfaces controls whether ToJSValue leaves out the struct fields of a function or interface type
+// that are nil, as if they had the omitempty option, instead of converting them into null. It is disabled by default.
+// Nil functions and interfaces found in maps, slices and arrays are always converted into null.
+func SetOmitNilFuncsAndInterfaces(enabled bool) {
+	updateConfig(WithOmitNilFuncsAndInterfaces(enabled))
+}
+
+// WithOmitNilFuncsAndInterfaces is the Option equivalent of SetOmitNilFuncsAndInterfaces, for a single conversion.
+func WithOmitNilFuncsAndInterfaces(enabled bool) Option {
+	return func(c *config) {
+		c.omitNilFuncsAndInterfaces = enabled
+	}
+}
+
+// SetJSONMarshalers controls whether ToJSValue converts values implementing json.Marshaler by parsing the JSON they
+// marshal into with JSON.parse, so that JS gets the representation the type intends for JSON rather than one built
+// from its fields. It is disabled by default, and special types such as time.Time keep their own conversion.
+func SetJSONMarshalers(enabled bool) {
+	updateConfig(WithJSONMarshalers(enabled))
+}
+
+// WithJSONMarshalers is the Option equivalent of SetJSONMarshalers, for a single conversion.
+func WithJSONMarshalers(enabled bool) Option {
+	return func(c *config) {
+		c.jsonMarshalers = enabled
+	}
+}
+
+// SetStrictStructs controls whether ToJSValueErr returns an error wrapping ErrEmptyStruct, and ToJSValue panics, for a
+// struct that has fields but would be converted into an empty object because none of them is exported and it has no
+// exported methods, which usually means that fields were not exported by mistake or that the struct belongs to
+// another package. Structs without any field, such as struct{}, are still converted into empty objects.
+// It is disabled by default.
+func SetStrictStructs(enabled bool) {
+	updateConfig(WithStrictStructs(enabled))
+}
+
+// WithStrictStructs is the Option equivalent of SetStrictStructs, for a single conversion.
+func WithStrictStructs(enabled bool) Option {
+	return func(c *config) {
+		c.strictStructs = enabled
+	}
+}
+
+// SetLenientArrays controls whether FromJSValue decodes a JS array whose length does not match the length of the Go
+// array it is decoded into, such as a [3]float64, instead of returning an InvalidArrayError. Missing elements are then
+// left to the zero value and extra elements are ignored. It is disabled by default.
+func SetLenientArrays(enabled bool) {
+	updateConfig(WithLenientArrays(enabled))
+}
+
+// WithLenientArrays is the Option equivalent of SetLenientArrays, for the arguments of the functions created by a
+// single conversion.
+func WithLenientArrays(enabled bool) Option {
+	return func(c *config) {
+		c.lenientArrays = enabled
+	}
+}
+
+// NameStrategy converts the name of a struct field without a name in its wasm tag into the name of its JS property.
+type NameStrategy func(string) string
+
+// SetNameStrategy sets the NameStrategy used by ToJSValue and FromJSValue for struct fields without a name in their
+// wasm tag. A nil strategy, the default, uses the Go field name as is.
+func SetNameStrategy(strategy NameStrategy) {
+	updateConfig(WithNameStrategy(strategy))
+}
+
+// WithNameStrategy is the Option equivalent of SetNameStrategy, for a single conversion.
+func WithNameStrategy(strategy NameStrategy) Option {
+	return func(c *config) {
+		c.nameStrategy = strategy
+	}
+}
+
+// CamelCase is a NameStrategy converting a Go field name into camelCase by lowercasing its leading upper case letters,
+// keeping the last one of an initialism upper case if it starts the next word.
+// For example, "Name" becomes "name", "UserID" becomes "userID" and "HTTPServer" becomes "httpServer".
+func CamelCase(name string) string {
+	runes := []rune(name)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// SnakeCase is a NameStrategy converting a Go field name into snake_case by lowercasing it and separating its words
+// with underscores. A word starts at an upper case letter following a lower case letter or a digit, and at the last
+// upper case letter of an initialism followed by a lower case letter, while digits belong to the word before them.
+// For example, "Name" becomes "name", "UserID" becomes "user_id", "HTTPServer" becomes "http_server" and "Base64Data"
+// becomes "base64_data".
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// jsName returns the name of the JS property of the provided struct field.
+func (c config) jsName(field structField) string {
+	if field.tagged || c.nameStrategy == nil {
+		return field.name
+	}
+	return c.nameStrategy(field.name)
+}
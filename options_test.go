@@ -0,0 +1,60 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"testing"
+)
+
+func TestNameStrategies(t *testing.T) {
+	tests := []struct {
+		name  string
+		camel string
+		snake string
+	}{
+		{"Name", "name", "name"},
+		{"UserID", "userID", "user_id"},
+		{"ID", "id", "id"},
+		{"HTTPServer", "httpServer", "http_server"},
+		{"ServeHTTP", "serveHTTP", "serve_http"},
+		{"Base64Data", "base64Data", "base64_data"},
+		{"Address2", "address2", "address2"},
+		{"HTTP2Server", "http2Server", "http2_server"},
+		{"X", "x", "x"},
+	}
+	for _, tt := range tests {
+		if got := CamelCase(tt.name); got != tt.camel {
+			t.Errorf("CamelCase(%q) = %q, want %q", tt.name, got, tt.camel)
+		}
+		if got := SnakeCase(tt.name); got != tt.snake {
+			t.Errorf("SnakeCase(%q) = %q, want %q", tt.name, got, tt.snake)
+		}
+	}
+}
+
+func TestSnakeCaseConversions(t *testing.T) {
+	type user struct {
+		UserID    int
+		FirstName string
+		Nick      string `wasm:"nickname"`
+	}
+	value := ToJSValueWith(user{UserID: 1, FirstName: "a", Nick: "b"}, WithNameStrategy(SnakeCase))
+	if got, want := jsonString(t, value), `{"user_id":1,"first_name":"a","nickname":"b"}`; got != want {
+		t.Errorf("ToJSValueWith() = %s, want %s", got, want)
+	}
+
+	SetNameStrategy(SnakeCase)
+	t.Cleanup(func() {
+		SetNameStrategy(nil)
+	})
+
+	var u user
+	obj := jsFunc(`return {user_id: 2, first_name: "c", nickname: "d"}`).Invoke()
+	if err := FromJSValue(obj, &u); err != nil {
+		t.Fatalf("FromJSValue() error = %v", err)
+	}
+	if u != (user{UserID: 2, FirstName: "c", Nick: "d"}) {
+		t.Errorf("FromJSValue() = %+v, want {UserID:2 FirstName:c Nick:d}", u)
+	}
+}
@@ -66,41 +66,112 @@ func NewPromise(handler func() (interface{}, error)) Promise {
 		return nil
 	})
 
-	promise, err := Global().Expect(js.TypeFunction, "Promise")
+	promise, err := constructor("Promise")
 	if err != nil {
-		panic("Promise constructor not found")
+		panic(err)
 	}
 
 	return mustJSValueToPromise(promise.New(jsHandler))
 }
 
+// NewPendingPromise returns a pending JS Promise along with the functions settling it, for Go code that hands a Promise
+// to JS before knowing its outcome, such as when starting an asynchronous operation. resolve fulfills the Promise with
+// the provided value converted with ToJSValue, and reject rejects it with the provided error converted with ToJSError.
+// Only the first call to either of them has an effect, like with the functions passed to a JS Promise executor.
+//
+// The executor passed to the Promise constructor is called synchronously, so its js.Func is released before
+// NewPendingPromise returns.
+func NewPendingPromise() (promise js.Value, resolve func(interface{}), reject func(error)) {
+	promiseConstructor, err := constructor("Promise")
+	if err != nil {
+		panic(err)
+	}
+
+	var jsResolve, jsReject js.Value
+	executor := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if len(args) < 2 {
+			panic("not enough arguments are passed to the Promise constructor handler")
+		}
+		jsResolve, jsReject = args[0], args[1]
+		return nil
+	})
+	defer executor.Release()
+
+	promise = promiseConstructor.New(executor)
+	resolve = func(value interface{}) {
+		jsResolve.Invoke(ToJSValue(value))
+	}
+	reject = func(err error) {
+		jsReject.Invoke(ToJSError(err))
+	}
+	return promise, resolve, reject
+}
+
 // Await waits for the Promise. It unmarshals the resolved value to v. An error
 // will be returned if unmarshalling is unsuccessful or the Promise rejects.
-// It is implemented by calling then and catch on JS.
+// It is implemented by calling Await, so the same restrictions apply.
 func (p Promise) Await(v interface{}) error {
-	err := make(chan error)
-	p.value.Call("then", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		if len(args) > 0 && v != nil {
-			err <- FromJSValue(args[0], v)
-			return nil
-		}
-		err <- nil
+	result, err := Await(p.value)
+	if err != nil {
+		return err
+	}
+	if v == nil {
 		return nil
-	}))
-	p.value.Call("catch", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		err <- errors.New(args[0].Call("toString").String())
+	}
+	return FromJSValue(result, v)
+}
+
+// Await blocks until the provided JS Promise settles, returning the value it is fulfilled with or an error holding the
+// String form of the reason it is rejected with. A value that is not a Promise is returned as is.
+// It is implemented by calling Promise.resolve and then on JS.
+//
+// Await must be called from its own goroutine, never directly from a function called by JS such as one exposed with
+// ToJSValue: the Promise can only settle once the JS event loop runs again, which is blocked until that function
+// returns.
+func Await(promise js.Value) (js.Value, error) {
+	type settled struct {
+		value js.Value
+		err   error
+	}
+
+	promiseConstructor, err := constructor("Promise")
+	if err != nil {
+		panic(err)
+	}
+
+	done := make(chan settled, 1)
+	onFulfilled := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		done <- settled{value: firstArg(args)}
 		return nil
-	}))
-	return <-err
+	})
+	defer onFulfilled.Release()
+	onRejected := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		done <- settled{err: errors.New(js.Global().Call("String", firstArg(args)).String())}
+		return nil
+	})
+	defer onRejected.Release()
+
+	promiseConstructor.Call("resolve", promise).Call("then", onFulfilled, onRejected)
+
+	result := <-done
+	return result.value, result.err
+}
+
+// firstArg returns the first of the provided arguments, or undefined if there are none.
+func firstArg(args []js.Value) js.Value {
+	if len(args) == 0 {
+		return js.Undefined()
+	}
+	return args[0]
 }
 
 // PromiseAll creates a promise that is fulfilled when all the provided promises have been fulfilled.
 // The promise is rejected when any of the promises provided rejects.
 // It is implemented by calling Promise.all on JS.
 func PromiseAll(promise ...Promise) Promise {
-	promiseAll, err := Global().Expect(js.TypeFunction, "Promise", "all")
+	promiseConstructor, err := constructor("Promise")
 	if err != nil {
-		panic("Promise.all not found")
+		panic(err)
 	}
 
 	pInterface := make([]interface{}, 0, len(promise))
@@ -108,15 +179,15 @@ func PromiseAll(promise ...Promise) Promise {
 		pInterface = append(pInterface, v)
 	}
 
-	return mustJSValueToPromise(promiseAll.Invoke(pInterface))
+	return mustJSValueToPromise(promiseConstructor.Call("all", ToJSValue(pInterface)))
 }
 
 // PromiseAllSettled creates a promise that is fulfilled when all the provided promises have been fulfilled or rejected.
 // It is implemented by calling Promise.allSettled on JS.
 func PromiseAllSettled(promise ...Promise) Promise {
-	promiseAllSettled, err := Global().Expect(js.TypeFunction, "Promise", "allSettled")
+	promiseConstructor, err := constructor("Promise")
 	if err != nil {
-		panic("Promise.allSettled not found")
+		panic(err)
 	}
 
 	pInterface := make([]interface{}, 0, len(promise))
@@ -124,16 +195,16 @@ func PromiseAllSettled(promise ...Promise) Promise {
 		pInterface = append(pInterface, v)
 	}
 
-	return mustJSValueToPromise(promiseAllSettled.Invoke(pInterface))
+	return mustJSValueToPromise(promiseConstructor.Call("allSettled", ToJSValue(pInterface)))
 }
 
 // PromiseAny creates a promise that is fulfilled when any of the provided promises have been fulfilled.
 // The promise is rejected when all of the provided promises gets rejected.
 // It is implemented by calling Promise.any on JS.
 func PromiseAny(promise ...Promise) Promise {
-	promiseAny, err := Global().Expect(js.TypeFunction, "Promise", "any")
+	promiseConstructor, err := constructor("Promise")
 	if err != nil {
-		panic("Promise.any not found")
+		panic(err)
 	}
 
 	pInterface := make([]interface{}, 0, len(promise))
@@ -141,15 +212,15 @@ func PromiseAny(promise ...Promise) Promise {
 		pInterface = append(pInterface, v)
 	}
 
-	return mustJSValueToPromise(promiseAny.Invoke(pInterface))
+	return mustJSValueToPromise(promiseConstructor.Call("any", ToJSValue(pInterface)))
 }
 
 // PromiseRace creates a promise that is fulfilled or rejected when one of the provided promises fulfill or reject.
 // It is implemented by calling Promise.race on JS.
 func PromiseRace(promise ...Promise) Promise {
-	promiseRace, err := Global().Expect(js.TypeFunction, "Promise", "race")
+	promiseConstructor, err := constructor("Promise")
 	if err != nil {
-		panic("Promise.race not found")
+		panic(err)
 	}
 
 	pInterface := make([]interface{}, 0, len(promise))
@@ -157,7 +228,7 @@ func PromiseRace(promise ...Promise) Promise {
 		pInterface = append(pInterface, v)
 	}
 
-	return mustJSValueToPromise(promiseRace.Invoke(pInterface))
+	return mustJSValueToPromise(promiseConstructor.Call("race", ToJSValue(pInterface)))
 }
 
 func mustJSValueToPromise(v js.Value) Promise {
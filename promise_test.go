@@ -0,0 +1,91 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"errors"
+	"syscall/js"
+	"testing"
+)
+
+func TestNewPendingPromise(t *testing.T) {
+	// awaiter stands for JS code awaiting the Promise, describing how it settles.
+	awaiter := jsFunc("p", `return (async () => {
+		try {
+			const value = await p;
+			return "fulfilled " + JSON.stringify(value);
+		} catch (e) {
+			return "rejected " + (e instanceof Error) + " " + e.goType + " " + e.message;
+		}
+	})()`)
+
+	type result struct {
+		ID int `wasm:"id"`
+	}
+	tests := []struct {
+		name   string
+		settle func(resolve func(interface{}), reject func(error))
+		want   string
+	}{
+		{"resolved", func(resolve func(interface{}), reject func(error)) {
+			resolve(result{ID: 1})
+		}, `fulfilled {"id":1}`},
+		{"rejected", func(resolve func(interface{}), reject func(error)) {
+			reject(errors.New("failed"))
+		}, "rejected true *errors.errorString failed"},
+		{"settled twice", func(resolve func(interface{}), reject func(error)) {
+			resolve(nil)
+			reject(errors.New("too late"))
+			resolve(2)
+		}, "fulfilled null"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promise, resolve, reject := NewPendingPromise()
+			described := awaiter.Invoke(promise)
+
+			// The Promise is settled later, from Go code that JS does not wait for.
+			go tt.settle(resolve, reject)
+
+			got, err := Await(described)
+			if err != nil {
+				t.Fatalf("Await() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("awaiting the Promise = %q, want %q", got.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestAwait(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		want    string
+		wantErr string
+	}{
+		{"fulfilled", "Promise.resolve(1)", "1", ""},
+		{"rejected", `Promise.reject(new Error("failed"))`, "", "Error: failed"},
+		{"rejected with a string", `Promise.reject("failed")`, "", "failed"},
+		{"not a Promise", `"value"`, "value", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := Await(jsFunc("return " + tt.source).Invoke())
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Await() error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Await() error = %v", err)
+			}
+			if got := js.Global().Call("String", value).String(); got != tt.want {
+				t.Errorf("Await() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -6,9 +6,14 @@ package gowasm
 import (
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"reflect"
+	"strconv"
+	"sync"
 	"syscall/js"
 	"time"
+	"unsafe"
 )
 
 // ErrMultipleReturnValue is an error where a JS function is attempted to be unmarshalled into a Go function with
@@ -23,6 +28,9 @@ type InvalidFromJSValueError struct {
 
 // Error implements error.
 func (e InvalidFromJSValueError) Error() string {
+	if e.Type == nil {
+		return "invalid argument passed to FromJSValue. Got nil"
+	}
 	return "invalid argument passed to FromJSValue. Got type " + e.Type.String()
 }
 
@@ -57,38 +65,110 @@ type Decoder interface {
 	FromJSValue(js.Value) error
 }
 
+// Unwrapper is the decoding counterpart of Wrapper, letting a type that encodes itself into a js.Value also decode
+// itself from one. It is the same interface as Decoder.
+type Unwrapper = Decoder
+
 // FromJSValue converts a given js.Value to the Go equivalent.
 // The new value of 'out' is undefined if FromJSValue returns an error.
 //
-// When a JS function is unmarshalled into a Go function with only one return value, the returned JS value is casted
-// into the type of the return value. If the conversion fails, the function call panics.
+// A JS value decoded into an interface{}, or into the values of a map[string]interface{}, gets a Go type inferred from
+// its JS type at any depth: booleans, numbers and strings become bool, float64 and string, arrays become
+// []interface{}, Dates become time.Time, Uint8Arrays and ArrayBuffers become []byte, other typed arrays of numbers
+// become []float64, and other objects become map[string]interface{}. Undefined and null become nil.
+//
+// A JS array is decoded into a slice or an array, as is an array-like object with a numeric length property such as a
+// NodeList or the arguments object.
+//
+// Targets are matched by their reflect.Kind rather than their exact type, so a JS number is decoded into a named
+// integer or float type such as an enum declared as type Status int, keeping that type, like into any other number.
+//
+// A Date is decoded into a time.Time from its epoch milliseconds, as is a string holding a time in the RFC 3339 format,
+// such as the ISO string of a Date. Other JS values cannot be decoded into a time.Time.
+//
+// A BigInt is decoded into an integer through its decimal form, returning an error if it overflows the integer, or
+// into a big.Int with arbitrary precision. Decoded into an interface{}, it becomes a *big.Int.
 //
-// When a JS function is unmarshalled into a Go function with two return values, the second one being error, the
-// conversion error is returned instead.
+// A JS function is unmarshalled into a Go function that converts its arguments with ToJSValue, spreading the variadic
+// ones, calls the JS function and converts the returned JS value into the type of its return value, if it has one.
+//
+// When the Go function has no error return value, a failed conversion panics, as does an exception thrown by the JS
+// function.
+//
+// When the last return value of the Go function is an error, failed conversions are returned instead, as are exceptions
+// thrown by the JS function as a js.Error. The Go function may have at most one other return value.
 func FromJSValue(x js.Value, out interface{}) error {
+	d := decoder{config: currentConfig()}
+	return d.fromJSValue(x, out)
+}
+
+// FromJSArray decodes the provided JS array into the slice that out points to, such as a *[]Person, allocating a new
+// slice of the length of the array and decoding each element like FromJSValue does, with structs decoded according to
+// their wasm tags. It is the decoding counterpart of converting a slice with ToJSValue.
+//
+// Unlike FromJSValue, it returns an InvalidTypeError if x is not an array, including when it is null or undefined. An
+// error decoding an element mentions its index. The slice that out points to is left unchanged on errors.
+func FromJSArray(x js.Value, out interface{}) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return &InvalidFromJSValueError{reflect.TypeOf(out)}
+	}
+
+	d := decoder{config: currentConfig()}
+	slice := v.Elem()
+	if isBigInt(x) {
+		return errBigIntInto(slice.Type())
+	}
+	if x.Type() != js.TypeObject || !isArray(x) {
+		return InvalidTypeError{x.Type(), slice.Type()}
+	}
+
+	jsLen := x.Length()
+	result := reflect.MakeSlice(slice.Type(), jsLen, jsLen)
+	for i := 0; i < jsLen; i++ {
+		if err := d.decodeValue(x.Index(i), result.Index(i)); err != nil {
+			return fmt.Errorf("in element %d: %w", i, err)
+		}
+	}
+	slice.Set(result)
+	return nil
+}
+
+// decoder holds the state of a single conversion from JS to Go.
+type decoder struct {
+	config config
+}
+
+// fromJSValue is FromJSValue with the config of d.
+func (d *decoder) fromJSValue(x js.Value, out interface{}) error {
 	v := reflect.ValueOf(out)
 	if v.Kind() != reflect.Ptr || v.IsNil() {
-		return &InvalidFromJSValueError{reflect.TypeOf(v)}
+		return &InvalidFromJSValueError{reflect.TypeOf(out)}
 	}
 
-	return decodeValue(x, v.Elem())
+	return d.decodeValue(x, v.Elem())
 }
 
 // decodeValue decodes the provided js.Value into the provided reflect.Value.
-func decodeValue(x js.Value, v reflect.Value) error {
+func (d *decoder) decodeValue(x js.Value, v reflect.Value) error {
+	// A BigInt is the only JS type that js.Value.Type panics on, so it is told apart first.
+	bigInt := isBigInt(x)
+
 	// If we have undefined or null, we need to be able to set to the pointer itself.
 	// All code beyond this point are pointer-unaware so we handle undefined or null first.
-	switch x.Type() {
-	case js.TypeUndefined:
-		// Keep the existing value if it is undefined.
-		return nil
-	case js.TypeNull:
-		return decodeNothing(v)
+	if !bigInt {
+		switch x.Type() {
+		case js.TypeUndefined:
+			// Keep the existing value if it is undefined.
+			return nil
+		case js.TypeNull:
+			return decodeNothing(v)
+		}
 	}
 
 	// Implementations of Decoder are probably on pointer so do it before pointer code.
-	if d, ok := v.Addr().Interface().(Decoder); ok {
-		return d.FromJSValue(x)
+	if u, ok := v.Addr().Interface().(Decoder); ok {
+		return u.FromJSValue(x)
 	}
 
 	// Make sure everything is initialized and indirect it.
@@ -96,6 +176,11 @@ func decodeValue(x js.Value, v reflect.Value) error {
 	if v.Kind() == reflect.Ptr {
 		initializePointerIfNil(v)
 		v = reflect.Indirect(v)
+
+		// The type pointed to may implement Decoder itself, e.g. a *T field where *T implements it.
+		if u, ok := v.Addr().Interface().(Decoder); ok {
+			return u.FromJSValue(x)
+		}
 	}
 
 	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
@@ -113,6 +198,10 @@ func decodeValue(x js.Value, v reflect.Value) error {
 		return nil
 	}
 
+	if bigInt {
+		return decodeBigInt(x, v)
+	}
+
 	// Go the reflection route.
 	switch x.Type() {
 	case js.TypeBoolean:
@@ -120,19 +209,27 @@ func decodeValue(x js.Value, v reflect.Value) error {
 	case js.TypeNumber:
 		return decodeNumber(x, v)
 	case js.TypeString:
+		if v.Type() == timeType {
+			return decodeTimeString(x, v)
+		}
 		return decodeString(x, v)
 	case js.TypeSymbol:
 		return decodeSymbol(x, v)
 	case js.TypeObject:
-		if isArray(x) {
-			return decodeArray(x, v)
+		if isByteSliceOrArray(v.Type()) {
+			if bytes, ok := asUint8Array(x); ok {
+				return d.decodeBytes(bytes, v)
+			}
 		}
-		if isDate(x) {
+		if isArray(x) || ((v.Kind() == reflect.Array || v.Kind() == reflect.Slice) && isArrayLike(x)) {
+			return d.decodeArray(x, v)
+		}
+		if isDate(x) || v.Type() == timeType {
 			return decodeDate(x, v)
 		}
-		return decodeObject(x, v)
+		return d.decodeObject(x, v)
 	case js.TypeFunction:
-		return decodeFunction(x, v)
+		return d.decodeFunction(x, v)
 	default:
 		panic("unknown JS type: " + x.Type().String())
 	}
@@ -156,8 +253,16 @@ func decodeBoolean(x js.Value, v reflect.Value) error {
 	return nil
 }
 
+var durationType = reflect.TypeOf(time.Duration(0))
+
 // decodeNumber decodes a JS number into the provided reflect.Value, truncating as necessary.
+// A number decoded into a time.Duration is treated as milliseconds.
 func decodeNumber(x js.Value, v reflect.Value) error {
+	if v.Type() == durationType {
+		v.SetInt(int64(x.Float() * float64(time.Millisecond)))
+		return nil
+	}
+
 	switch v.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		v.SetInt(int64(x.Float()))
@@ -171,6 +276,29 @@ func decodeNumber(x js.Value, v reflect.Value) error {
 	return nil
 }
 
+// decodeNumberString decodes a JS string holding a decimal number into the provided reflect.Value.
+// It is used for struct fields with the string option.
+func decodeNumberString(x js.Value, v reflect.Value) error {
+	var err error
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		n, err = strconv.ParseInt(x.String(), 10, v.Type().Bits())
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		n, err = strconv.ParseUint(x.String(), 10, v.Type().Bits())
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		var n float64
+		n, err = strconv.ParseFloat(x.String(), v.Type().Bits())
+		v.SetFloat(n)
+	default:
+		return decodeString(x, v)
+	}
+	return err
+}
+
 // decodeString decodes a JS string into the provided reflect.Value.
 func decodeString(x js.Value, v reflect.Value) error {
 	if v.Kind() != reflect.String {
@@ -187,23 +315,31 @@ func decodeSymbol(x js.Value, v reflect.Value) error {
 }
 
 // decodeArray decodes a JS array into the provided reflect.Value.
-func decodeArray(x js.Value, v reflect.Value) error {
+func (d *decoder) decodeArray(x js.Value, v reflect.Value) error {
 	jsLen := x.Length()
 
 	switch v.Kind() {
 	case reflect.Array:
 		if jsLen != v.Len() {
-			return InvalidArrayError{v.Len(), jsLen}
+			if !d.config.lenientArrays {
+				return InvalidArrayError{v.Len(), jsLen}
+			}
+			v.Set(reflect.Zero(v.Type()))
+			if jsLen > v.Len() {
+				jsLen = v.Len()
+			}
 		}
 	case reflect.Slice:
 		newSlice := reflect.MakeSlice(v.Type(), jsLen, jsLen)
 		v.Set(newSlice)
+	case reflect.Complex64, reflect.Complex128:
+		return d.decodeArrayIntoComplex(x, v)
 	default:
 		return InvalidTypeError{js.TypeObject, v.Type()}
 	}
 
 	for i := 0; i < jsLen; i++ {
-		err := FromJSValue(x.Index(i), v.Index(i).Addr().Interface())
+		err := d.fromJSValue(x.Index(i), v.Index(i).Addr().Interface())
 		if err != nil {
 			return err
 		}
@@ -211,62 +347,188 @@ func decodeArray(x js.Value, v reflect.Value) error {
 	return nil
 }
 
-// decodeDate decodes a JS date into the provided reflect.Value.
+// decodeBigInt decodes a JS BigInt into the provided reflect.Value, which must be an integer, a float or a big.Int,
+// going through its decimal form so that no bit is lost. It returns an error if the BigInt overflows an integer.
+func decodeBigInt(x js.Value, v reflect.Value) error {
+	decimal := bigIntString(x)
+	if n, ok := v.Addr().Interface().(*big.Int); ok {
+		n.SetString(decimal, 10)
+		return nil
+	}
+
+	var err error
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		n, err = strconv.ParseInt(decimal, 10, v.Type().Bits())
+		if err == nil {
+			v.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var n uint64
+		n, err = strconv.ParseUint(decimal, 10, v.Type().Bits())
+		if err == nil {
+			v.SetUint(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		var n float64
+		n, err = strconv.ParseFloat(decimal, v.Type().Bits())
+		v.SetFloat(n)
+	default:
+		return errBigIntInto(v.Type())
+	}
+	if err != nil {
+		return fmt.Errorf("invalid unmarshalling: cannot unmarshal bigint %s into %v: %w", decimal, v.Type(), err)
+	}
+	return nil
+}
+
+// errBigIntInto returns the error of decoding a BigInt into a Go type that cannot hold it. InvalidTypeError cannot
+// be used as js.Type has no value for BigInts.
+func errBigIntInto(t reflect.Type) error {
+	return fmt.Errorf("invalid unmarshalling: cannot unmarshal bigint into %v", t)
+}
+
+var (
+	objectToStringOnce sync.Once
+	objectToString     js.Value
+)
+
+// isBigInt reports whether the provided js.Value is a BigInt, on which js.Value.Type panics, according to
+// Object.prototype.toString.
+func isBigInt(x js.Value) bool {
+	objectToStringOnce.Do(func() {
+		objectConstructor, err := constructor("Object")
+		if err != nil {
+			panic(err)
+		}
+		objectToString = objectConstructor.Get("prototype").Get("toString")
+	})
+	return objectToString.Call("call", x).String() == "[object BigInt]"
+}
+
+// bigIntString returns the decimal form of the provided BigInt.
+func bigIntString(x js.Value) string {
+	stringFunc, err := constructor("String")
+	if err != nil {
+		panic(err)
+	}
+	return stringFunc.Invoke(x).String()
+}
+
+// decodeBytes decodes a JS Uint8Array into the provided byte slice or byte array, copying it all at once.
+func (d *decoder) decodeBytes(x js.Value, v reflect.Value) error {
+	jsLen := x.Length()
+
+	if v.Kind() == reflect.Array {
+		if jsLen != v.Len() {
+			if !d.config.lenientArrays {
+				return InvalidArrayError{v.Len(), jsLen}
+			}
+			v.Set(reflect.Zero(v.Type()))
+		}
+		js.CopyBytesToGo(v.Slice(0, v.Len()).Bytes(), x)
+		return nil
+	}
+
+	newSlice := reflect.MakeSlice(v.Type(), jsLen, jsLen)
+	js.CopyBytesToGo(newSlice.Bytes(), x)
+	v.Set(newSlice)
+	return nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// decodeDate decodes a JS date into the provided reflect.Value, which must be a time.Time like any object decoded into
+// a time.Time must be a Date.
 func decodeDate(x js.Value, v reflect.Value) error {
 	t, ok := v.Addr().Interface().(*time.Time)
-	if !ok {
+	if !ok || !isDate(x) {
 		return InvalidTypeError{js.TypeObject, v.Type()}
 	}
-	millis := x.Call("getTime").Int()
+	millis := x.Call("getTime").Float()
+	if math.IsNaN(millis) {
+		return errors.New("invalid unmarshalling: cannot unmarshal an invalid Date into time.Time")
+	}
 	*t = time.UnixMilli(int64(millis))
 	return nil
 }
 
+// decodeTimeString decodes a JS string holding a time in the RFC 3339 format, such as the ISO strings returned by
+// Date.prototype.toISOString, into the provided time.Time.
+func decodeTimeString(x js.Value, v reflect.Value) error {
+	t, err := time.Parse(time.RFC3339, x.String())
+	if err != nil {
+		return fmt.Errorf("invalid unmarshalling: cannot unmarshal string into time.Time: %w", err)
+	}
+	v.Set(reflect.ValueOf(t))
+	return nil
+}
+
 // decodeObject decodes a JS object into the provided reflect.Value.
-func decodeObject(x js.Value, v reflect.Value) error {
+func (d *decoder) decodeObject(x js.Value, v reflect.Value) error {
 	switch v.Kind() {
 	case reflect.Struct:
-		return decodeObjectIntoStruct(x, v)
+		return d.decodeObjectIntoStruct(x, v)
 	case reflect.Map:
-		return decodeObjectIntoMap(x, v)
+		return d.decodeObjectIntoMap(x, v)
 	case reflect.Complex64, reflect.Complex128:
-		return decodeObjectIntoComplex(x, v)
+		return d.decodeObjectIntoComplex(x, v)
 	default:
 		return InvalidTypeError{js.TypeObject, v.Type()}
 	}
 }
 
-// decodeObject decodes a JS object into the provided reflect.Value struct.
-func decodeObjectIntoStruct(x js.Value, v reflect.Value) error {
-	for i := 0; i < v.Type().NumField(); i++ {
-		fieldType := v.Type().Field(i)
-		if fieldType.PkgPath != "" {
+// decodeObjectIntoStruct decodes a JS object into the provided reflect.Value struct, reading the same properties as
+// structToJSObject writes: the fields of anonymous embedded structs, and pointers to structs, are promoted like
+// encoding/json does, with the fields of the outer struct taking precedence over promoted fields of the same name.
+func (d *decoder) decodeObjectIntoStruct(x js.Value, v reflect.Value) error {
+	for _, field := range cachedStructFields(v.Type(), false) {
+		name := d.config.jsName(field)
+		jsField := x.Get(name)
+		if jsField.IsUndefined() {
+			// Keep the existing value, without allocating the embedded pointers the field is promoted through.
 			continue
 		}
 
-		name := fieldType.Name
-		tagName, tagOK := fieldType.Tag.Lookup("wasm")
-
-		if tagOK {
-			if tagName == "-" {
-				continue
+		fieldValue, err := fieldByIndexAlloc(v, field.index)
+		if err == nil {
+			if field.asString && !isBigInt(jsField) && jsField.Type() == js.TypeString {
+				err = decodeNumberString(jsField, fieldValue)
+			} else {
+				err = d.decodeValue(jsField, fieldValue)
 			}
-			name = tagName
 		}
-
-		err := decodeValue(x.Get(name), v.Field(i))
 		if err != nil {
-			if tagOK {
-				return fmt.Errorf("in field %s (JS %s): %w", fieldType.Name, tagName, err)
+			if field.tagged {
+				return fmt.Errorf("in field %s (JS %s): %w", field.goName, name, err)
 			}
-			return fmt.Errorf("in field %s: %w", fieldType.Name, err)
+			return fmt.Errorf("in field %s: %w", field.goName, err)
 		}
 	}
 
 	return nil
 }
 
-func decodeObjectIntoMap(x js.Value, v reflect.Value) error {
+// fieldByIndexAlloc is like reflect.Value.FieldByIndex, except that it allocates the nil embedded pointers to structs
+// that the field is promoted through. It returns an error if one of them is unexported and cannot be set.
+func fieldByIndexAlloc(v reflect.Value, index []int) (reflect.Value, error) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, fmt.Errorf("cannot set embedded pointer to unexported struct %v", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, nil
+}
+
+func (d *decoder) decodeObjectIntoMap(x js.Value, v reflect.Value) error {
 	mapType := v.Type()
 	keyType := mapType.Key()
 	valType := mapType.Elem()
@@ -281,40 +543,32 @@ func decodeObjectIntoMap(x js.Value, v reflect.Value) error {
 		return InvalidTypeError{js.TypeObject, mapType}
 	}
 
-	// TODO: Use Object API
-	obj, err := Global().Get("Object")
-	if err != nil {
-		panic("Object not found")
-	}
+	entries := ObjectEntries(x)
+	v.Set(reflect.MakeMapWithSize(mapType, len(entries)))
 
-	var keys []string
-	err = FromJSValue(obj.Call("keys", x), &keys)
-	if err != nil {
-		panic("Object.keys returned non-string-array.")
-	}
-
-	v.Set(reflect.MakeMapWithSize(mapType, len(keys)))
-
-	for _, k := range keys {
+	for _, entry := range entries {
 		valuePtr := reflect.New(valType).Interface()
-		err := FromJSValue(x.Get(k), valuePtr)
+		err := d.fromJSValue(entry.Value, valuePtr)
 		if err != nil {
 			return err
 		}
 
-		v.SetMapIndex(reflect.ValueOf(k), reflect.ValueOf(valuePtr).Elem())
+		v.SetMapIndex(reflect.ValueOf(entry.Key), reflect.ValueOf(valuePtr).Elem())
 	}
 	return nil
 }
 
-// decodeObjectIntoComplex decodes the provided object into a complex number.
-func decodeObjectIntoComplex(x js.Value, v reflect.Value) error {
+// decodeObjectIntoComplex decodes the provided object into a complex number, reading the properties named by the
+// ComplexFormat set with SetComplexFormat.
+func (d *decoder) decodeObjectIntoComplex(x js.Value, v reflect.Value) error {
+	realName, imagName := d.config.complexFormat.names()
+
 	var r, i float64
-	err := FromJSValue(x.Get("real"), &r)
+	err := d.fromJSValue(x.Get(realName), &r)
 	if err != nil {
 		return err
 	}
-	err = FromJSValue(x.Get("imag"), &i)
+	err = d.fromJSValue(x.Get(imagName), &i)
 	if err != nil {
 		return err
 	}
@@ -323,58 +577,113 @@ func decodeObjectIntoComplex(x js.Value, v reflect.Value) error {
 	return nil
 }
 
-// decodeFunction decodes a JS function into the provided reflect.Value.
-func decodeFunction(x js.Value, v reflect.Value) error {
-	funcType := v.Type()
-	outCount := funcType.NumOut()
+// decodeArrayIntoComplex decodes the provided [real, imag] array into a complex number.
+func (d *decoder) decodeArrayIntoComplex(x js.Value, v reflect.Value) error {
+	if x.Length() != 2 {
+		return InvalidArrayError{2, x.Length()}
+	}
 
-	switch outCount {
-	case 0, 1:
-	case 2:
-		if funcType.Out(1) != errorType {
-			return ErrMultipleReturnValue
+	var parts [2]float64
+	for i := range parts {
+		err := d.fromJSValue(x.Index(i), &parts[i])
+		if err != nil {
+			return err
 		}
-	default:
+	}
+
+	v.SetComplex(complex(parts[0], parts[1]))
+	return nil
+}
+
+// decodeFunction decodes a JS function into the provided reflect.Value.
+func (d *decoder) decodeFunction(x js.Value, v reflect.Value) error {
+	if v.Kind() != reflect.Func {
+		return InvalidTypeError{js.TypeFunction, v.Type()}
+	}
+
+	funcType := v.Type()
+	hasError := returnsError(funcType)
+	valueCount := funcType.NumOut()
+	if hasError {
+		valueCount--
+	}
+	if valueCount > 1 {
 		return ErrMultipleReturnValue
 	}
 
-	v.Set(reflect.MakeFunc(funcType, func(args []reflect.Value) []reflect.Value {
-		argsJS := make([]interface{}, 0, len(args))
-		for _, v := range args {
-			argsJS = append(argsJS, ToJSValue(v.Interface()))
-		}
+	v.Set(reflect.MakeFunc(funcType, func(args []reflect.Value) (results []reflect.Value) {
+		// fail returns err as the error return value, or panics with it if there is none.
+		fail := func(err error) []reflect.Value {
+			if !hasError {
+				panic(err)
+			}
 
-		jsReturn := x.Invoke(argsJS...)
-		if outCount == 0 {
-			return []reflect.Value{}
+			var out []reflect.Value
+			if valueCount == 1 {
+				out = append(out, reflect.Zero(funcType.Out(0)))
+			}
+			return append(out, reflect.ValueOf(&err).Elem())
 		}
 
-		returnPtr := reflect.New(funcType.Out(0)).Interface()
-		err := FromJSValue(jsReturn, returnPtr)
+		if hasError {
+			defer func() {
+				if r := recover(); r != nil {
+					jsErr, ok := r.(js.Error)
+					if !ok {
+						panic(r)
+					}
+					results = fail(jsErr)
+				}
+			}()
+		}
 
-		returnVal := reflect.ValueOf(returnPtr).Elem()
-		if err != nil {
-			if outCount == 1 {
-				panic("error decoding JS return value: " + err.Error())
+		if funcType.IsVariadic() {
+			variadic := args[len(args)-1]
+			args = args[:len(args)-1]
+			for i := 0; i < variadic.Len(); i++ {
+				args = append(args, variadic.Index(i))
 			}
+		}
 
-			return []reflect.Value{returnVal, reflect.ValueOf(err)}
+		argsJS := make([]interface{}, 0, len(args))
+		for _, arg := range args {
+			e := encoder{config: d.config}
+			argJS, err := e.toJSValue(arg.Interface())
+			if err != nil {
+				return fail(err)
+			}
+			argsJS = append(argsJS, argJS)
 		}
 
-		switch outCount {
-		case 1:
-			return []reflect.Value{returnVal}
-		case 2:
-			return []reflect.Value{returnVal, reflect.Zero(v.Type())}
-		default:
-			panic("unexpected amount of return values")
+		jsReturn := x.Invoke(argsJS...)
+
+		var out []reflect.Value
+		if valueCount == 1 {
+			returnPtr := reflect.New(funcType.Out(0))
+			if err := d.fromJSValue(jsReturn, returnPtr.Interface()); err != nil {
+				return fail(fmt.Errorf("error decoding JS return value: %w", err))
+			}
+			out = append(out, returnPtr.Elem())
 		}
+		if hasError {
+			out = append(out, reflect.Zero(errorType))
+		}
+		return out
 	}))
 	return nil
 }
 
-// createInterface creates a representation of the provided js.Value.
+// createInterface creates a representation of the provided js.Value, inferring the Go types from the JS ones:
+// undefined and null become nil, booleans become bool, numbers become float64, strings become string, arrays become
+// []interface{}, Dates become time.Time, Uint8Arrays and ArrayBuffers become []byte, other typed arrays of numbers
+// become []float64, functions become func(...interface{}) (interface{}, error) and other objects become
+// map[string]interface{}, recursively.
 func createInterface(x js.Value) interface{} {
+	if isBigInt(x) {
+		n, _ := new(big.Int).SetString(bigIntString(x), 10)
+		return n
+	}
+
 	switch x.Type() {
 	case js.TypeUndefined, js.TypeNull:
 		return nil
@@ -391,6 +700,17 @@ func createInterface(x js.Value) interface{} {
 		if isArray(x) {
 			return createArray(x)
 		}
+		if isDate(x) {
+			return time.UnixMilli(int64(x.Call("getTime").Float()))
+		}
+		if bytes, ok := asUint8Array(x); ok {
+			b := make([]byte, bytes.Length())
+			js.CopyBytesToGo(b, bytes)
+			return b
+		}
+		if isNumberTypedArray(x) {
+			return createFloats(x)
+		}
 		return createObject(x)
 	case js.TypeFunction:
 		var a func(...interface{}) (interface{}, error)
@@ -413,42 +733,107 @@ func createArray(x js.Value) interface{} {
 	return result
 }
 
-// createObject creates a representation of the provided JS object.
-func createObject(x js.Value) interface{} {
-	// TODO: Use Object API
-	obj, err := Global().Get("Object")
-	if err != nil {
-		panic("Object not found")
+// numberTypedArrays are the names of the typed arrays holding numbers other than bytes, which createInterface decodes
+// into a []float64.
+var numberTypedArrays = []string{
+	"Int8Array", "Int16Array", "Int32Array", "Uint16Array", "Uint32Array", "Float32Array", "Float64Array",
+}
+
+// isNumberTypedArray reports whether the provided js.Value is one of numberTypedArrays.
+func isNumberTypedArray(x js.Value) bool {
+	for _, name := range numberTypedArrays {
+		typedArray, err := constructor(name)
+		if err != nil {
+			panic(err)
+		}
+		if x.InstanceOf(typedArray) {
+			return true
+		}
 	}
+	return false
+}
 
-	var keys []string
-	err = FromJSValue(obj.Call("keys", x), &keys)
+// createFloats copies the provided typed array into a []float64 at once, through a Float64Array holding its numbers.
+func createFloats(x js.Value) []float64 {
+	result := make([]float64, x.Length())
+	if len(result) == 0 {
+		return result
+	}
+
+	float64Array, err := constructor("Float64Array")
+	if err != nil {
+		panic(err)
+	}
+	uint8Array, err := constructor("Uint8Array")
 	if err != nil {
-		panic("Object.keys returned non-string-array.")
+		panic(err)
 	}
 
-	result := make(map[string]interface{}, len(keys))
-	for _, v := range keys {
-		result[v] = createInterface(x.Get(v))
+	floats := float64Array.New(x)
+	view := uint8Array.New(floats.Get("buffer"), floats.Get("byteOffset"), floats.Get("byteLength"))
+	js.CopyBytesToGo(unsafe.Slice((*byte)(unsafe.Pointer(&result[0])), len(result)*8), view)
+	return result
+}
+
+// createObject creates a representation of the provided JS object.
+func createObject(x js.Value) interface{} {
+	entries := ObjectEntries(x)
+	result := make(map[string]interface{}, len(entries))
+	for _, entry := range entries {
+		result[entry.Key] = createInterface(entry.Value)
 	}
 	return result
 }
 
 // isArray calls the JS function Array.isArray to check if the provided js.Value is an array.
 func isArray(x js.Value) bool {
-	arr, err := Global().Get("Array")
+	arr, err := constructor("Array")
 	if err != nil {
-		panic("Array not found")
+		panic(err)
 	}
 
 	return arr.Call("isArray", x).Bool()
 }
 
+// isArrayLike reports whether the provided JS object has a numeric length property, like typed arrays, the arguments
+// object and DOM collections such as NodeList and HTMLCollection, so that its elements can be read by index even
+// though it is not an Array.
+func isArrayLike(x js.Value) bool {
+	return x.Get("length").Type() == js.TypeNumber
+}
+
+// isByteSliceOrArray reports whether the provided type is a slice or an array of bytes.
+func isByteSliceOrArray(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.Uint8
+}
+
+// asUint8Array returns the provided js.Value if it is a Uint8Array, or a Uint8Array viewing it if it is an ArrayBuffer.
+// It returns false if it is neither.
+func asUint8Array(x js.Value) (js.Value, bool) {
+	uint8Array, err := constructor("Uint8Array")
+	if err != nil {
+		panic(err)
+	}
+	if x.InstanceOf(uint8Array) {
+		return x, true
+	}
+
+	arrayBuffer, err := constructor("ArrayBuffer")
+	if err != nil {
+		panic(err)
+	}
+	if x.InstanceOf(arrayBuffer) {
+		return uint8Array.New(x), true
+	}
+
+	return js.Value{}, false
+}
+
 // isDate uses x instanceof Date to check if the provided js.Value is a Date.
 func isDate(x js.Value) bool {
-	date, err := Global().Get("Date")
+	date, err := constructor("Date")
 	if err != nil {
-		panic("Date not found")
+		panic(err)
 	}
 
 	return x.InstanceOf(date)
@@ -0,0 +1,388 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall/js"
+	"testing"
+	"time"
+)
+
+type testStatus int
+
+type testLevelFloat float32
+
+func TestFromJSValueNamedNumbers(t *testing.T) {
+	type record struct {
+		Status   testStatus
+		Level    testLevelFloat
+		Pointer  *testStatus
+		Statuses []testStatus
+		ByName   map[string]testStatus
+	}
+
+	var r record
+	obj := jsFunc(`return {Status: 2, Level: 1.5, Pointer: 3, Statuses: [4, 5], ByName: {a: 6}}`).Invoke()
+	if err := FromJSValue(obj, &r); err != nil {
+		t.Fatalf("FromJSValue() error = %v", err)
+	}
+
+	if r.Status != 2 || r.Level != 1.5 || r.Pointer == nil || *r.Pointer != 3 {
+		t.Errorf("FromJSValue() = %+v, want Status 2, Level 1.5 and Pointer to 3", r)
+	}
+	if len(r.Statuses) != 2 || r.Statuses[0] != 4 || r.Statuses[1] != 5 {
+		t.Errorf("Statuses = %v, want [4 5]", r.Statuses)
+	}
+	if r.ByName["a"] != 6 {
+		t.Errorf("ByName = %v, want map[a:6]", r.ByName)
+	}
+}
+
+func TestIsBigInt(t *testing.T) {
+	tests := []struct {
+		source string
+		want   bool
+	}{
+		{"10n", true},
+		{"10", false},
+		{`"10n"`, false},
+		{"({})", false},
+		{"null", false},
+		{"undefined", false},
+	}
+	for _, tt := range tests {
+		if got := isBigInt(jsFunc("return " + tt.source).Invoke()); got != tt.want {
+			t.Errorf("isBigInt(%s) = %t, want %t", tt.source, got, tt.want)
+		}
+	}
+}
+
+type testHex uint32
+
+func (h testHex) JSValue() js.Value {
+	return js.ValueOf("0x" + strconv.FormatUint(uint64(h), 16))
+}
+
+func (h *testHex) FromJSValue(v js.Value) error {
+	if v.Type() != js.TypeString || !strings.HasPrefix(v.String(), "0x") {
+		return errors.New("not a hex string")
+	}
+	n, err := strconv.ParseUint(strings.TrimPrefix(v.String(), "0x"), 16, 32)
+	*h = testHex(n)
+	return err
+}
+
+var _ interface {
+	Wrapper
+	Unwrapper
+} = new(testHex)
+
+func TestFromJSValueUnwrapperRoundTrip(t *testing.T) {
+	type record struct {
+		Color  testHex
+		Colors []testHex
+	}
+	in := record{Color: 0xff00, Colors: []testHex{0x1, 0xabc}}
+	value := ToJSValue(in)
+	if got := jsonString(t, value); got != `{"Color":"0xff00","Colors":["0x1","0xabc"]}` {
+		t.Errorf("ToJSValue() = %s, want {\"Color\":\"0xff00\",\"Colors\":[\"0x1\",\"0xabc\"]}", got)
+	}
+
+	var out record
+	if err := FromJSValue(value, &out); err != nil {
+		t.Fatalf("FromJSValue() error = %v", err)
+	}
+	if out.Color != in.Color || len(out.Colors) != 2 || out.Colors[0] != 0x1 || out.Colors[1] != 0xabc {
+		t.Errorf("FromJSValue() = %+v, want %+v", out, in)
+	}
+
+	if err := FromJSValue(js.ValueOf(12), &out.Color); err == nil || !strings.Contains(err.Error(), "not a hex string") {
+		t.Errorf("FromJSValue(12) error = %v, want the error of FromJSValue", err)
+	}
+}
+
+func TestFromJSValueInferredTypes(t *testing.T) {
+	obj := jsFunc(`return {
+		name: "a",
+		count: 2,
+		ok: true,
+		none: null,
+		missing: undefined,
+		tags: ["x", 1, [false]],
+		nested: {at: new Date(1700000000123), bytes: new Uint8Array([1, 2]), floats: new Int16Array([-1, 3])},
+		big: 12345678901234567890n,
+	}`).Invoke()
+	n, _ := new(big.Int).SetString("12345678901234567890", 10)
+	want := map[string]interface{}{
+		"name":    "a",
+		"count":   2.0,
+		"ok":      true,
+		"none":    nil,
+		"missing": nil,
+		"tags":    []interface{}{"x", 1.0, []interface{}{false}},
+		"nested": map[string]interface{}{
+			"at":     time.UnixMilli(1700000000123),
+			"bytes":  []byte{1, 2},
+			"floats": []float64{-1, 3},
+		},
+		"big": n,
+	}
+
+	var m map[string]interface{}
+	if err := FromJSValue(obj, &m); err != nil {
+		t.Fatalf("FromJSValue() into a map error = %v", err)
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("FromJSValue() into a map = %#v, want %#v", m, want)
+	}
+
+	var i interface{}
+	if err := FromJSValue(obj, &i); err != nil {
+		t.Fatalf("FromJSValue() into an interface error = %v", err)
+	}
+	if !reflect.DeepEqual(i, want) {
+		t.Errorf("FromJSValue() into an interface = %#v, want %#v", i, want)
+	}
+}
+
+func TestFromJSValueArrays(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		lenient bool
+		want    [3]float64
+		wantErr *InvalidArrayError
+	}{
+		{"exact", "[1, 2, 3]", false, [3]float64{1, 2, 3}, nil},
+		{"too short", "[1, 2]", false, [3]float64{}, &InvalidArrayError{Expected: 3, Actual: 2}},
+		{"too long", "[1, 2, 3, 4]", false, [3]float64{}, &InvalidArrayError{Expected: 3, Actual: 4}},
+		{"lenient exact", "[1, 2, 3]", true, [3]float64{1, 2, 3}, nil},
+		{"lenient too short", "[1, 2]", true, [3]float64{1, 2, 0}, nil},
+		{"lenient too long", "[1, 2, 3, 4]", true, [3]float64{1, 2, 3}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetLenientArrays(tt.lenient)
+			t.Cleanup(func() {
+				SetLenientArrays(false)
+			})
+
+			// The array starts out filled, so that elements missing from a lenient decoding are seen to be zeroed.
+			out := [3]float64{9, 9, 9}
+			err := FromJSValue(jsFunc("return "+tt.source).Invoke(), &out)
+			if tt.wantErr != nil {
+				var arrayErr InvalidArrayError
+				if !errors.As(err, &arrayErr) || arrayErr != *tt.wantErr {
+					t.Errorf("FromJSValue() error = %v, want %v", err, *tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromJSValue() error = %v", err)
+			}
+			if out != tt.want {
+				t.Errorf("FromJSValue() = %v, want %v", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromJSArray(t *testing.T) {
+	type person struct {
+		Name string `wasm:"name"`
+		Age  int    `wasm:"age"`
+	}
+	people := jsFunc(`return [{name: "a", age: 1}, {name: "b", age: 2}, {name: "c", age: 3}]`).Invoke()
+	var out []person
+	if err := FromJSArray(people, &out); err != nil {
+		t.Fatalf("FromJSArray() error = %v", err)
+	}
+	want := []person{{"a", 1}, {"b", 2}, {"c", 3}}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("FromJSArray() = %+v, want %+v", out, want)
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"object", `({name: "a"})`, "cannot unmarshal object into []gowasm.person"},
+		{"null", `null`, "cannot unmarshal null into []gowasm.person"},
+		{"undefined", `undefined`, "cannot unmarshal undefined into []gowasm.person"},
+		{"invalid element", `[{name: "a"}, {name: 2}]`, "in element 1: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept := []person{{"kept", 0}}
+			err := FromJSArray(jsFunc("return "+tt.source).Invoke(), &kept)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("FromJSArray() error = %v, want it to contain %q", err, tt.want)
+			}
+			if len(kept) != 1 || kept[0].Name != "kept" {
+				t.Errorf("FromJSArray() changed the slice to %+v on error", kept)
+			}
+		})
+	}
+
+	var notASlice [3]person
+	var invalidErr *InvalidFromJSValueError
+	if err := FromJSArray(people, &notASlice); !errors.As(err, &invalidErr) {
+		t.Errorf("FromJSArray() into an array error = %v, want an InvalidFromJSValueError", err)
+	}
+}
+
+func TestFromJSValueBigInts(t *testing.T) {
+	huge := "123456789012345678901234567890"
+	for _, x := range []interface{}{int64(math.MaxInt64), int64(math.MinInt64), uint64(math.MaxUint64)} {
+		out := reflect.New(reflect.TypeOf(x))
+		if err := FromJSValue(ToJSValue(x), out.Interface()); err != nil {
+			t.Errorf("FromJSValue(ToJSValue(%v)) error = %v", x, err)
+			continue
+		}
+		if got := out.Elem().Interface(); got != x {
+			t.Errorf("FromJSValue(ToJSValue(%v)) = %v", x, got)
+		}
+	}
+
+	n, _ := new(big.Int).SetString(huge, 10)
+	var out *big.Int
+	if err := FromJSValue(ToJSValue(n), &out); err != nil {
+		t.Fatalf("FromJSValue() into a *big.Int error = %v", err)
+	}
+	if out.Cmp(n) != 0 {
+		t.Errorf("FromJSValue() into a *big.Int = %v, want %v", out, n)
+	}
+
+	overflows := []struct {
+		source string
+		out    interface{}
+	}{
+		{huge + "n", new(int64)},
+		{"9223372036854775808n", new(int64)},
+		{"-1n", new(uint64)},
+		{"256n", new(uint8)},
+	}
+	for _, tt := range overflows {
+		if err := FromJSValue(jsFunc("return "+tt.source).Invoke(), tt.out); err == nil {
+			t.Errorf("FromJSValue(%s) into %T did not fail", tt.source, tt.out)
+		}
+	}
+}
+
+func TestFromJSValueTimes(t *testing.T) {
+	want := time.Date(2024, time.March, 5, 6, 7, 8, 9000000, time.UTC)
+	tests := []struct {
+		name    string
+		source  string
+		want    time.Time
+		wantErr bool
+	}{
+		{"Date", "new Date(Date.UTC(2024, 2, 5, 6, 7, 8, 9))", want, false},
+		{"ISO string", `"2024-03-05T06:07:08.009Z"`, want, false},
+		{"RFC 3339 string with an offset", `"2024-03-05T13:07:08.009+07:00"`, want, false},
+		{"invalid Date", "new Date(NaN)", time.Time{}, true},
+		{"invalid string", `"yesterday"`, time.Time{}, true},
+		{"number", "1709618828009", time.Time{}, true},
+		{"object", "({})", time.Time{}, true},
+		{"boolean", "true", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got time.Time
+			err := FromJSValue(jsFunc("return "+tt.source).Invoke(), &got)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("FromJSValue() = %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FromJSValue() error = %v", err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("FromJSValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// A Date cannot be decoded into anything but a time.Time.
+	var s string
+	if err := FromJSValue(jsFunc("return new Date()").Invoke(), &s); err == nil {
+		t.Errorf("FromJSValue() of a Date into a string = %q, want an error", s)
+	}
+}
+
+func TestFromJSValueArrayLikes(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"array-like object", `({length: 2, 0: "a", 1: "b"})`, "a,b"},
+		{"arguments", `(function() { return arguments; })("a", "b", "c")`, "a,b,c"},
+		{"typed array", `new Int16Array([1, -2])`, "1,-2"},
+		{"array", `["a"]`, "a"},
+		{"empty array-like object", `({length: 0})`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out []js.Value
+			if err := FromJSValue(jsFunc("return "+tt.source).Invoke(), &out); err != nil {
+				t.Fatalf("FromJSValue() error = %v", err)
+			}
+			strs := make([]string, len(out))
+			for i, v := range out {
+				strs[i] = js.Global().Call("String", v).String()
+			}
+			if got := strings.Join(strs, ","); got != tt.want {
+				t.Errorf("FromJSValue() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Only slices and arrays accept array-likes, which are decoded into other types as objects.
+	var m map[string]interface{}
+	if err := FromJSValue(jsFunc(`return {length: 1, 0: "a"}`).Invoke(), &m); err != nil || m["0"] != "a" {
+		t.Errorf("FromJSValue() into a map = %v, %v, want the object's properties", m, err)
+	}
+}
+
+type testTimestamps struct {
+	Created int    `wasm:"created"`
+	Note    string `wasm:"note"`
+}
+
+func TestFromJSValueEmbeddedRoundTrip(t *testing.T) {
+	type post struct {
+		testTimestamps
+		testBase
+		Title string `wasm:"title"`
+		Note  string `wasm:"note"` // Takes precedence over the promoted Note.
+	}
+	in := post{
+		testTimestamps: testTimestamps{Created: 1, Note: "inner"},
+		testBase:       testBase{ID: 2},
+		Title:          "t",
+		Note:           "outer",
+	}
+	value := ToJSValue(in)
+	if got := jsonString(t, value); got != `{"created":1,"id":2,"title":"t","note":"outer"}` {
+		t.Errorf("ToJSValue() = %s, want {\"created\":1,\"id\":2,\"title\":\"t\",\"note\":\"outer\"}", got)
+	}
+
+	var out post
+	if err := FromJSValue(value, &out); err != nil {
+		t.Fatalf("FromJSValue() error = %v", err)
+	}
+	if out.Created != 1 || out.ID != 2 || out.Title != "t" || out.Note != "outer" || out.testTimestamps.Note != "" {
+		t.Errorf("FromJSValue() = %+v, want the outer Note set and the promoted one left empty", out)
+	}
+}
@@ -4,201 +4,1633 @@
 package gowasm
 
 import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall/js"
 	"time"
 	"unsafe"
 )
 
+// ErrUnsupportedType is wrapped by a ConversionError when a Go value has a type that has no JS equivalent.
+var ErrUnsupportedType = errors.New("unsupported type")
+
+// ErrUnsupportedMapKey is wrapped by a ConversionError when a map has a key type that cannot be used as a JS object
+// key.
+var ErrUnsupportedMapKey = errors.New(
+	"map key is not a string, a number, a bool, a fmt.Stringer or an encoding.TextMarshaler")
+
+// ErrMaxDepth is wrapped by a ConversionError when a Go value is nested deeper than the limit set with SetMaxDepth.
+var ErrMaxDepth = errors.New("maximum nesting depth exceeded")
+
+// ErrEmptyStruct is wrapped by a ConversionError when SetStrictStructs is enabled and a struct with fields would be
+// converted into an empty object, as none of its fields is exported and it has no exported methods.
+var ErrEmptyStruct = errors.New("struct has no exported fields or methods")
+
+// ConversionError is returned by ToJSValueErr when a Go value cannot be converted into a JS value.
+type ConversionError struct {
+	// Path is the location of the offending value inside the value passed to ToJSValueErr, such as
+	// "Addresses[2].Coordinates". It is empty if the offending value is the value passed in itself.
+	Path string
+	// Root is the type of the value passed to ToJSValueErr, which Path starts from.
+	Root reflect.Type
+	Type reflect.Type
+	Kind reflect.Kind
+	Err  error
+}
+
+// Error implements error.
+func (e ConversionError) Error() string {
+	msg := fmt.Sprintf("cannot convert %v to a JS value (kind %s)", e.Type, e.Kind)
+	if e.Path != "" {
+		// The path is prefixed with the root type, such as "main.User.Addresses[2].Coordinates" or "[]main.User[2].Name".
+		path := e.Path
+		if e.Root != nil {
+			if !strings.HasPrefix(path, "[") {
+				path = "." + path
+			}
+			path = e.Root.String() + path
+		}
+		msg += " at " + path
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the underlying reason of the conversion failure.
+func (e ConversionError) Unwrap() error {
+	return e.Err
+}
+
 // Wrapper is an interface which manually encodes to js.Value.
 // It overrides in ToJSValue.
 type Wrapper interface {
 	JSValue() js.Value
 }
 
+// JSMarshaler is an interface which manually encodes to js.Value and may fail.
+// It overrides in ToJSValue, after Wrapper. The returned error is wrapped in the ConversionError returned by
+// ToJSValueErr.
+type JSMarshaler interface {
+	MarshalJS() (js.Value, error)
+}
+
+// JSMethodNamer is an interface which renames or hides the methods of a struct that ToJSValue attaches to its JS
+// object, as methods cannot have struct tags.
+// JSMethodNames maps Go method names to the JS names they are attached under, or to an empty string to not attach
+// them. Methods missing from it keep their Go name, and JSMethodNames itself is never attached.
+type JSMethodNamer interface {
+	JSMethodNames() map[string]string
+}
+
 // ToJSValue converts a given Go value into its equivalent JS form.
 //
-// One special case is that complex numbers (complex64 and complex128) are converted into objects with a real and imag
-// property holding a number each.
+// A value is converted by the first of the following that applies: its Wrapper implementation, its JSMarshaler
+// implementation, the converter registered for its type with RegisterConverter, and finally the rules for its type.
+// Numbers, strings and bools are converted into their JS equivalent, with integers beyond Number.MAX_SAFE_INTEGER
+// converted into BigInts and complex numbers into objects with a real and imag property. Byte slices and slices of
+// other fixed-width numbers are copied into typed arrays, other slices and arrays are converted into arrays, and maps
+// and structs into objects. Pointers and interfaces are converted like the value they hold, and a nil pointer into
+// undefined. Functions are converted into JS functions calling them, and channels and iter.Seq values into iterables.
+// Types of the standard library such as time.Time, time.Duration, big.Int, json.RawMessage, net.IP and bytes.Buffer
+// are converted into their natural JS form, like a Date for a time.Time, and other values implementing
+// encoding.TextMarshaler into strings.
 //
-// A function is converted into a JS function where the function returns an error if the provided arguments do not conform
-// to the Go equivalent but otherwise calls the Go function.
+// Pointers, maps and slices that are encountered more than once, including ones forming a cycle, are converted only the
+// first time. Every later occurrence refers to the same JS value.
 //
-// The "this" argument of a function is always passed to the Go function if its first parameter is of type js.Value.
-// Otherwise, it is simply ignored.
+// The Set functions of the package, such as SetNilPointersAsNull or SetMaxDepth, adjust these rules.
 //
-// If the last return value of a function is an error, it will be thrown in JS if it's non-nil.
-// If the function returns multiple non-error values, it is converted to an array when returning to JS.
-//
-// It panics when a channel or a map with keys other than string and integers are passed in.
+// It panics when a value cannot be converted, such as a map with unsupported keys or a value nested too deeply.
+// Use ToJSValueErr to get an error instead.
 func ToJSValue(x interface{}) js.Value {
+	value, err := ToJSValueErr(x)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// ToJSValueErr is like ToJSValue but returns a ConversionError instead of panicking when the value, or any value
+// nested inside of it, cannot be converted.
+func ToJSValueErr(x interface{}) (js.Value, error) {
+	e := encoder{config: currentConfig()}
+	return e.toJSValue(x)
+}
+
+// ToJSValueWith converts a given Go value like ToJSValue, with the provided options applied on top of the package-level
+// settings for this conversion only. The Go functions it converts keep using these options when JS calls them, both to
+// decode their arguments, such as the fields of a struct parameter named with WithNameStrategy, and to convert their
+// return values.
+//
+// It panics when ToJSValue would. Use ToJSValueWithErr to get an error instead.
+func ToJSValueWith(x interface{}, opts ...Option) js.Value {
+	value, err := ToJSValueWithErr(x, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// ToJSValueWithErr is like ToJSValueWith but returns a ConversionError instead of panicking.
+func ToJSValueWithErr(x interface{}, opts ...Option) (js.Value, error) {
+	e := encoder{config: configWith(opts)}
+	return e.toJSValue(x)
+}
+
+// ToJSMap converts a given Go value like ToJSValue, except that every Go map is converted into a JS Map instead of a
+// plain object, as does x itself when it is a struct converted into an object of its fields. Unlike an object, a Map
+// does not collide with properties such as "constructor" or "toString", preserves insertion order and accepts keys of
+// any type, so map keys are converted with the same rules as values. Structs that ToJSValue converts specially, such
+// as a time.Time or a Wrapper, are converted the same way as by ToJSValue.
+//
+// It panics when ToJSValue would. Use ToJSMapErr to get an error instead.
+func ToJSMap(x interface{}) js.Value {
+	value, err := ToJSMapErr(x)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// ToJSMapErr is like ToJSMap but returns a ConversionError instead of panicking.
+func ToJSMapErr(x interface{}) (js.Value, error) {
+	e := encoder{config: currentConfig()}
+	e.config.jsMaps = true
+
+	// Only structs converted into an object of their fields are turned into a Map. Other structs, such as a time.Time
+	// or a Wrapper, are converted like ToJSValue does.
+	value := reflect.Indirect(reflect.ValueOf(x))
+	if value.Kind() != reflect.Struct || specialStructTypes[value.Type()] ||
+		hasCustomConversion(value.Type()) || hasCustomConversion(reflect.TypeOf(x)) {
+		return e.toJSValue(x)
+	}
+
+	obj, err := e.toJSValue(x)
+	if err != nil {
+		return js.Value{}, err
+	}
+
+	mapConstructor, err := constructor("Map")
+	if err != nil {
+		return js.Value{}, e.errorf(value, err)
+	}
+	objectEntries, err := constructorProperty("Object", "entries", js.TypeFunction)
+	if err != nil {
+		return js.Value{}, e.errorf(value, err)
+	}
+	return e.record(mapConstructor.New(objectEntries.Invoke(obj))), nil
+}
+
+// ToJSObject converts the provided map into a JS object like ToJSValue, without going through reflection to iterate it.
+// It is meant for objects built by hand such as options, whose values are converted with ToJSValue. Unlike ToJSValue, a
+// nil map is always converted into an empty object, and the map is never converted into a JS Map.
+//
+// It panics when ToJSValue would on one of the values. Use ToJSObjectErr to get an error instead.
+func ToJSObject(m map[string]interface{}) js.Value {
+	value, err := ToJSObjectErr(m)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// ToJSObjectErr is like ToJSObject but returns a ConversionError instead of panicking.
+func ToJSObjectErr(m map[string]interface{}) (js.Value, error) {
+	e := encoder{config: currentConfig()}
+
+	objectConstructor, err := constructor("Object")
+	if err != nil {
+		return js.Value{}, e.errorf(reflect.ValueOf(m), err)
+	}
+
+	obj := e.record(objectConstructor.New())
+	if m != nil {
+		e.remember(visitKey{ptr: reflect.ValueOf(m).Pointer(), typ: reflect.TypeOf(m)}, obj)
+	}
+
+	for key, x := range m {
+		value, err := e.toJSValueAt(pathSegment{key: reflect.ValueOf(key)}, x)
+		if err != nil {
+			return js.Value{}, err
+		}
+		obj.Set(key, value)
+	}
+	return obj, nil
+}
+
+// ToURLSearchParams converts the provided url.Values into a JS URLSearchParams, appending every value of a key in
+// order so that repeated keys are preserved, with the keys sorted like url.Values.Encode does. An http.Header can be
+// converted the same way as url.Values(header).
+//
+// ToJSValue keeps converting url.Values like any other map, into an object of arrays of strings.
+// ToURLSearchParams panics if the URLSearchParams constructor cannot be found. Use ToURLSearchParamsErr to get an
+// error instead.
+func ToURLSearchParams(values url.Values) js.Value {
+	value, err := ToURLSearchParamsErr(values)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// ToURLSearchParamsErr is like ToURLSearchParams but returns a ConversionError instead of panicking.
+func ToURLSearchParamsErr(values url.Values) (js.Value, error) {
+	e := encoder{config: currentConfig()}
+
+	searchParamsConstructor, err := constructor("URLSearchParams")
+	if err != nil {
+		return js.Value{}, e.errorf(reflect.ValueOf(values), err)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	params := e.record(searchParamsConstructor.New())
+	for _, key := range keys {
+		for _, value := range values[key] {
+			params.Call("append", key, value)
+		}
+	}
+	return params, nil
+}
+
+// ToJSSet converts the provided Go slice or array into a JS Set, adding its elements converted with ToJSValue in order.
+// As with any Set, duplicate elements are only added once, the first time they occur, and the Set iterates in that
+// order. Elements are compared with SameValueZero: converted strings, numbers and bools are deduplicated by value,
+// while every converted struct or map is a distinct object, unless it is reached through the same pointer.
+//
+// ToJSSet panics if x is not a slice or an array or if an element cannot be converted. Use ToJSSetErr to get an error
+// instead.
+func ToJSSet(x interface{}) js.Value {
+	value, err := ToJSSetErr(x)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// ToJSSetErr is like ToJSSet but returns a ConversionError instead of panicking.
+func ToJSSetErr(x interface{}) (js.Value, error) {
+	e := encoder{config: currentConfig()}
+
+	value := reflect.ValueOf(x)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		if !value.IsValid() {
+			return js.Value{}, fmt.Errorf("cannot convert nil into a Set: %w", ErrUnsupportedType)
+		}
+		return js.Value{}, e.errorf(value, ErrUnsupportedType)
+	}
+	e.root = value.Type()
+
+	setConstructor, err := constructor("Set")
+	if err != nil {
+		return js.Value{}, e.errorf(value, err)
+	}
+
+	set := e.record(setConstructor.New())
+	for i := 0; i < value.Len(); i++ {
+		elem, err := e.toJSValueAt(pathSegment{index: i}, interfaceOf(value.Index(i)))
+		if err != nil {
+			return js.Value{}, err
+		}
+		set.Call("add", elem)
+	}
+	return set, nil
+}
+
+// encoder holds the state of a single conversion from Go to JS.
+type encoder struct {
+	config    config
+	converter *Converter
+	path      []pathSegment
+	root      reflect.Type // Type of the value the conversion started from, for ConversionError.
+	visited   map[visitKey]js.Value
+	freeze    bool       // Whether the objects and arrays created by the conversion are recorded in created.
+	created   []js.Value // Objects and arrays created by the conversion, which ToJSFrozen freezes once it is done.
+}
+
+// derive returns an encoder for a new conversion with the same config and Converter as e.
+func (e *encoder) derive() *encoder {
+	return &encoder{config: e.config, converter: e.converter}
+}
+
+// visitKey identifies a struct, map or slice that has already been converted.
+type visitKey struct {
+	ptr    uintptr
+	typ    reflect.Type
+	length int
+}
+
+// pathSegment is one step from a value into one of its elements.
+type pathSegment struct {
+	field string        // Set when stepping into a struct field.
+	key   reflect.Value // Set when stepping into a map value.
+	index int           // Used otherwise, when stepping into an array or slice element.
+}
+
+// errNotSpecial is returned by specialToJSValue for values that are converted according to their reflect.Kind.
+var errNotSpecial = errors.New("not a special value")
+
+// toJSValue converts the provided Go value into its equivalent JS form.
+func (e *encoder) toJSValue(x interface{}) (js.Value, error) {
+	if v, ok := x.(reflect.Value); ok {
+		if !v.IsValid() {
+			return js.Null(), nil
+		}
+		if len(e.path) == 0 && e.root == nil {
+			e.root = v.Type()
+		}
+		if !v.CanInterface() {
+			return e.reflectToJSValue(v)
+		}
+		x = v.Interface()
+	}
 	if x == nil {
-		return js.Null()
+		return js.Null(), nil
+	}
+	if len(e.path) == 0 && e.root == nil {
+		e.root = reflect.TypeOf(x)
+	}
+
+	value, err := e.specialToJSValue(x)
+	if err != errNotSpecial {
+		return value, err
+	}
+	return e.reflectToJSValue(reflect.ValueOf(x))
+}
+
+// specialToJSValue converts the provided non-nil Go value if it is not converted according to its reflect.Kind, such as
+// Wrapper implementations, types with a registered converter and basic types that do not require reflection.
+// It returns errNotSpecial otherwise.
+func (e *encoder) specialToJSValue(x interface{}) (js.Value, error) {
+	if w, ok := x.(Wrapper); ok {
+		return w.JSValue(), nil
+	}
+	if m, ok := x.(JSMarshaler); ok {
+		value, err := m.MarshalJS()
+		if err != nil {
+			return js.Value{}, e.errorf(reflect.ValueOf(x), err)
+		}
+		return value, nil
+	}
+	if convert, ok := lookupConverter(reflect.TypeOf(x)); ok {
+		return convert(x), nil
 	}
 
 	// Fast path for basic types that do not require reflection.
 	switch x := x.(type) {
-	case Wrapper:
-		return x.JSValue()
 	case js.Value:
-		return x
-	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr,
-		unsafe.Pointer, float32, float64, string:
-		return js.ValueOf(x)
-	case complex64:
-		return js.ValueOf(map[string]interface{}{
-			"real": real(x),
-			"imag": imag(x),
+		return x, nil
+	case js.Error:
+		return x.Value, nil
+	case error:
+		if v := reflect.ValueOf(x); v.Kind() == reflect.Ptr && v.IsNil() {
+			return js.Null(), nil
+		}
+
+		errorConstructor, err := constructor("Error")
+		if err != nil {
+			return js.Value{}, e.errorf(reflect.ValueOf(x), err)
+		}
+		return e.record(errorConstructor.New(x.Error())), nil
+	case bool, int8, int16, int32, uint8, uint16, uint32, uintptr, unsafe.Pointer, string:
+		return js.ValueOf(x), nil
+	case json.RawMessage:
+		return e.rawJSONToJSValue(x)
+	case net.IP:
+		if len(x) == 0 {
+			return js.ValueOf(""), nil
+		}
+		return js.ValueOf(x.String()), nil
+	case netip.Addr:
+		if !x.IsValid() {
+			return js.ValueOf(""), nil
+		}
+		return js.ValueOf(x.String()), nil
+	case []string:
+		if hasElemConverter(x) {
+			break
+		}
+		return e.basicSliceToJSArray(x, unsafe.Pointer(unsafe.SliceData(x)), len(x), func(i int) (js.Value, error) {
+			return js.ValueOf(x[i]), nil
 		})
-	case complex128:
-		return js.ValueOf(map[string]interface{}{
-			"real": real(x),
-			"imag": imag(x),
+	case []js.Value:
+		if hasElemConverter(x) {
+			break
+		}
+		return e.basicSliceToJSArray(x, unsafe.Pointer(unsafe.SliceData(x)), len(x), func(i int) (js.Value, error) {
+			return x[i], nil
 		})
+	case []bool:
+		if hasElemConverter(x) {
+			break
+		}
+		return e.basicSliceToJSArray(x, unsafe.Pointer(unsafe.SliceData(x)), len(x), func(i int) (js.Value, error) {
+			return js.ValueOf(x[i]), nil
+		})
+	case []int:
+		if hasElemConverter(x) {
+			break
+		}
+		return e.basicSliceToJSArray(x, unsafe.Pointer(unsafe.SliceData(x)), len(x), func(i int) (js.Value, error) {
+			if n := int64(x[i]); -maxSafeInteger <= n && n <= maxSafeInteger {
+				return js.ValueOf(n), nil
+			}
+			return e.toJSValueAt(pathSegment{index: i}, x[i])
+		})
+	case float32:
+		return e.floatToJSValue(float64(x)), nil
+	case float64:
+		return e.floatToJSValue(x), nil
+	case int:
+		return e.intToJSValue(reflect.ValueOf(x), int64(x))
+	case int64:
+		return e.intToJSValue(reflect.ValueOf(x), x)
+	case uint:
+		return e.uintToJSValue(reflect.ValueOf(x), uint64(x))
+	case uint64:
+		return e.uintToJSValue(reflect.ValueOf(x), x)
+	case complex64:
+		return e.complexToJSValue(reflect.ValueOf(x), complex128(x))
+	case complex128:
+		return e.complexToJSValue(reflect.ValueOf(x), x)
+	case *big.Int:
+		if x == nil {
+			return js.Null(), nil
+		}
+		return e.bigIntToJSValue(reflect.ValueOf(x), x.String())
+	case big.Int:
+		return e.bigIntToJSValue(reflect.ValueOf(x), x.String())
+	case *big.Float:
+		if x == nil {
+			return js.Null(), nil
+		}
+		return e.bigFloatToJSValue(x), nil
+	case big.Float:
+		return e.bigFloatToJSValue(&x), nil
+	case *sync.Map:
+		if x == nil {
+			return js.Value{}, errNotSpecial
+		}
+		return e.syncMapToJSObject(x)
+	case *bytes.Buffer:
+		if x == nil {
+			return js.Null(), nil
+		}
+		return e.bufferToJSValue(x)
+	case bytes.Buffer:
+		return e.bufferToJSValue(&x)
+	case *strings.Builder:
+		if x == nil {
+			return js.Null(), nil
+		}
+		return js.ValueOf(x.String()), nil
+	case strings.Builder:
+		return js.ValueOf(x.String()), nil
+	case time.Duration:
+		return js.ValueOf(float64(x) / float64(time.Millisecond)), nil
 	case time.Time:
-		date, err := Global().Get("Date")
+		return e.timeToJSValue(x)
+	case *time.Time:
+		if x == nil {
+			return js.Value{}, errNotSpecial
+		}
+		return e.timeToJSValue(*x)
+	}
+
+	if m, ok := x.(json.Marshaler); ok && e.config.jsonMarshalers {
+		if v := reflect.ValueOf(x); v.Kind() == reflect.Ptr && v.IsNil() {
+			return js.Value{}, errNotSpecial
+		}
+
+		data, err := m.MarshalJSON()
 		if err != nil {
-			panic("Date constructor not found")
+			return js.Value{}, e.errorf(reflect.ValueOf(x), err)
 		}
-		return date.New(x.Format(time.RFC3339))
+		return e.rawJSONToJSValue(data)
 	}
 
-	value := reflect.ValueOf(x)
+	if m, ok := x.(encoding.TextMarshaler); ok {
+		if v := reflect.ValueOf(x); v.Kind() == reflect.Ptr && v.IsNil() {
+			return js.Value{}, errNotSpecial
+		}
 
-	if value.Kind() == reflect.Ptr {
-		value = reflect.Indirect(value)
-		if !value.IsValid() {
-			return js.Undefined()
+		text, err := m.MarshalText()
+		if err != nil {
+			return js.Value{}, e.errorf(reflect.ValueOf(x), err)
+		}
+		return js.ValueOf(string(text)), nil
+	}
+
+	if s, ok := x.(fmt.Stringer); ok && e.config.stringerEnums && isStringerEnum(reflect.TypeOf(x)) {
+		return js.ValueOf(s.String()), nil
+	}
+
+	return js.Value{}, errNotSpecial
+}
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+// isStringerEnum reports whether the provided type is an integer type implementing fmt.Stringer, such as an enum,
+// which SetStringerEnums converts into strings.
+func isStringerEnum(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return t.Implements(stringerType)
+	}
+	return false
+}
+
+// bufferToJSValue converts the unread contents of the provided bytes.Buffer into a JS string, or into a Uint8Array if
+// SetBuffersAsBytes is enabled.
+func (e *encoder) bufferToJSValue(x *bytes.Buffer) (js.Value, error) {
+	if e.config.buffersAsBytes {
+		return e.toJSUint8Array(reflect.ValueOf(x), x.Bytes())
+	}
+	return js.ValueOf(x.String()), nil
+}
+
+// timeToJSValue converts the provided time.Time into a Date, or into null if it is the zero time and
+// SetZeroTimesAsNull is enabled.
+func (e *encoder) timeToJSValue(x time.Time) (js.Value, error) {
+	if x.IsZero() && e.config.zeroTimesAsNull {
+		return js.Null(), nil
+	}
+
+	date, err := constructor("Date")
+	if err != nil {
+		return js.Value{}, e.errorf(reflect.ValueOf(x), err)
+	}
+	if e.config.rfc3339Dates {
+		return e.record(date.New(x.Format(time.RFC3339))), nil
+	}
+	return e.record(date.New(x.UnixMilli())), nil
+}
+
+// rawJSONToJSValue parses the provided JSON with JSON.parse. A nil json.RawMessage is converted into null like
+// encoding/json does.
+func (e *encoder) rawJSONToJSValue(x json.RawMessage) (js.Value, error) {
+	if x == nil {
+		return js.Null(), nil
+	}
+	if !json.Valid(x) {
+		return js.Value{}, e.errorf(reflect.ValueOf(x), errors.New("invalid JSON"))
+	}
+
+	parse, err := Global().Expect(js.TypeFunction, "JSON", "parse")
+	if err != nil {
+		return js.Value{}, e.errorf(reflect.ValueOf(x), err)
+	}
+	value := parse.Invoke(string(x))
+	if e.freeze {
+		if err := e.recordParsed(value); err != nil {
+			return js.Value{}, e.errorf(reflect.ValueOf(x), err)
+		}
+	}
+	return value, nil
+}
+
+// recordParsed records the provided value parsed from JSON like record, along with every object and array nested in
+// it, which were all created by JSON.parse.
+func (e *encoder) recordParsed(value js.Value) error {
+	if value.Type() != js.TypeObject {
+		return nil
+	}
+
+	objectKeys, err := constructorProperty("Object", "keys", js.TypeFunction)
+	if err != nil {
+		return err
+	}
+
+	e.record(value)
+	keys := objectKeys.Invoke(value)
+	for i := 0; i < keys.Length(); i++ {
+		if err := e.recordParsed(value.Get(keys.Index(i).String())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// complexToJSValue converts the provided complex number into a JS object or array as set with SetComplexFormat.
+// The real part is always set first, so that the properties of the object are in a stable order.
+func (e *encoder) complexToJSValue(x reflect.Value, c complex128) (js.Value, error) {
+	format := e.config.complexFormat
+	if format.AsArray {
+		return e.record(js.ValueOf([]interface{}{real(c), imag(c)})), nil
+	}
+
+	objectConstructor, err := constructor("Object")
+	if err != nil {
+		return js.Value{}, e.errorf(x, err)
+	}
+	realName, imagName := format.names()
+	obj := e.record(objectConstructor.New())
+	obj.Set(realName, real(c))
+	obj.Set(imagName, imag(c))
+	return obj, nil
+}
+
+// floatToJSValue converts the provided float into a JS number, or into what the config asks for if it is NaN or an
+// infinity.
+func (e *encoder) floatToJSValue(f float64) js.Value {
+	if !math.IsNaN(f) && !math.IsInf(f, 0) {
+		return js.ValueOf(f)
+	}
+
+	switch e.config.nonFiniteFloats {
+	case NonFiniteAsString:
+		switch {
+		case math.IsNaN(f):
+			return js.ValueOf("NaN")
+		case f > 0:
+			return js.ValueOf("Infinity")
+		default:
+			return js.ValueOf("-Infinity")
 		}
+	case NonFiniteAsNull:
+		return js.Null()
+	default:
+		return js.ValueOf(f)
 	}
+}
 
-	switch k := value.Kind(); k {
+// reflectToJSValue converts the provided Go value into its equivalent JS form according to its reflect.Kind.
+func (e *encoder) reflectToJSValue(value reflect.Value) (js.Value, error) {
+	switch value.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return e.indirectToJSValue(value)
 	case reflect.Bool:
-		return js.ValueOf(value.Bool())
+		return js.ValueOf(value.Bool()), nil
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return js.ValueOf(value.Int())
+		return e.intToJSValue(value, value.Int())
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return js.ValueOf(value.Uint())
+		return e.uintToJSValue(value, value.Uint())
 	case reflect.Uintptr:
-		return js.ValueOf(value.Pointer())
+		return js.ValueOf(value.Pointer()), nil
 	case reflect.Float32, reflect.Float64:
-		return js.ValueOf(value.Float())
+		return e.floatToJSValue(value.Float()), nil
+	case reflect.Complex64, reflect.Complex128:
+		return e.complexToJSValue(value, value.Complex())
 	case reflect.String:
-		return js.ValueOf(value.String())
-	case reflect.Array, reflect.Slice:
-		return toJSArray(value)
+		return js.ValueOf(value.String()), nil
+	case reflect.Slice:
+		if value.IsNil() && e.config.nilCollectionsAsNull {
+			return js.Null(), nil
+		}
+
+		if e.typedArrayElem(value.Type().Elem()) {
+			elemKind := value.Type().Elem().Kind()
+			if elemKind == reflect.Uint8 {
+				return e.toJSUint8Array(value, value.Bytes())
+			}
+			if constructorName, ok := typedArrayConstructors[elemKind]; ok {
+				return e.toJSTypedArray(value, constructorName)
+			}
+		}
+		if convert := cachedSliceConverter(value.Type()); convert != nil && value.CanInterface() {
+			// The converter is compiled once for every config, but converters can be registered for the element type
+			// at any time.
+			elemType := value.Type().Elem()
+			if !(e.config.stringerEnums && isStringerEnum(elemType)) && !hasCustomConversion(elemType) {
+				return convert(e, value)
+			}
+		}
+		return e.toJSArray(value)
+	case reflect.Array:
+		if value.Type().Elem().Kind() == reflect.Uint8 && e.typedArrayElem(value.Type().Elem()) {
+			return e.toJSUint8Array(value, arrayBytes(value))
+		}
+		return e.toJSArray(value)
 	case reflect.Func:
-		return toJSFunc(value)
+		if value.IsNil() {
+			return js.Null(), nil
+		}
+		if !value.CanInterface() {
+			// A function obtained through an unexported struct field cannot be called.
+			return js.Value{}, e.errorf(value, ErrUnsupportedType)
+		}
+		if isSeq(value.Type()) {
+			return e.seqToJSIterable(value)
+		}
+		return e.toJSFunc(value, funcName(value)), nil
 	case reflect.Map:
-		return mapToJSObject(value)
+		if value.IsNil() && e.config.nilCollectionsAsNull {
+			return js.Null(), nil
+		}
+		return e.mapToJSObject(value)
 	case reflect.Struct:
-		return structToJSObject(value)
+		if value.Type() == syncMapType {
+			// A sync.Map must not be copied once used, so it is ranged over through its address, copying the
+			// value only if it is not addressable.
+			if !value.CanAddr() {
+				copied := reflect.New(syncMapType).Elem()
+				copied.Set(value)
+				value = copied
+			}
+			return e.syncMapToJSObject(value.Addr().Interface().(*sync.Map))
+		}
+		return e.structToJSObject(value)
+	case reflect.Chan:
+		if !value.CanInterface() {
+			// A channel obtained through an unexported struct field can be neither received from nor sent to.
+			return js.Value{}, e.errorf(value, ErrUnsupportedType)
+		}
+		if value.Type().ChanDir()&reflect.RecvDir == 0 {
+			return e.chanToJSSendFunc(value), nil
+		}
+		return e.chanToJSAsyncIterable(value)
 	default:
-		panic(fmt.Sprintf("cannot convert %T to a JS value (kind %s)", x, k))
+		return js.Value{}, e.errorf(value, ErrUnsupportedType)
+	}
+}
+
+// indirectToJSValue converts the value that the provided pointer or interface points to, through as many levels of
+// indirection as necessary. A nil pointer at any level is converted into undefined and a nil interface into null.
+func (e *encoder) indirectToJSValue(value reflect.Value) (js.Value, error) {
+	if value.IsNil() {
+		if value.Kind() == reflect.Interface || e.config.nilPointersAsNull {
+			return js.Null(), nil
+		}
+		return js.Undefined(), nil
+	}
+
+	if value.Kind() == reflect.Ptr {
+		if visited, ok := e.visited[visitKey{ptr: value.Pointer(), typ: value.Type().Elem()}]; ok {
+			return visited, nil
+		}
+	}
+
+	// The value pointed to may be special itself, e.g. a *time.Time or a **T where *T implements Wrapper.
+	elem := value.Elem()
+	if elem.CanInterface() {
+		value, err := e.specialToJSValue(elem.Interface())
+		if err != errNotSpecial {
+			return value, err
+		}
+	}
+	return e.reflectToJSValue(elem)
+}
+
+// maxSafeInteger is the largest integer that a JS number can represent exactly, Number.MAX_SAFE_INTEGER.
+const maxSafeInteger = 1<<53 - 1
+
+// intToJSValue converts the integer n held by x into a JS number, or into a BigInt if it is not a safe integer.
+func (e *encoder) intToJSValue(x reflect.Value, n int64) (js.Value, error) {
+	if -maxSafeInteger <= n && n <= maxSafeInteger {
+		return js.ValueOf(n), nil
+	}
+	return e.bigIntToJSValue(x, strconv.FormatInt(n, 10))
+}
+
+// uintToJSValue converts the unsigned integer n held by x into a JS number, or into a BigInt if it is not a safe
+// integer.
+func (e *encoder) uintToJSValue(x reflect.Value, n uint64) (js.Value, error) {
+	if n <= maxSafeInteger {
+		return js.ValueOf(n), nil
+	}
+	return e.bigIntToJSValue(x, strconv.FormatUint(n, 10))
+}
+
+// bigIntToJSValue converts the decimal integer held by x into a JS BigInt.
+func (e *encoder) bigIntToJSValue(x reflect.Value, decimal string) (js.Value, error) {
+	bigInt, err := constructor("BigInt")
+	if err != nil {
+		return js.Value{}, e.errorf(x, err)
+	}
+	return bigInt.Invoke(decimal), nil
+}
+
+// bigFloatToJSValue converts the provided big.Float into the nearest JS number, or into a JS string holding its
+// shortest decimal form that parses back into it if requested by the config.
+func (e *encoder) bigFloatToJSValue(x *big.Float) js.Value {
+	if e.config.bigFloatsAsStrings {
+		return js.ValueOf(x.Text('g', -1))
+	}
+	f, _ := x.Float64()
+	return js.ValueOf(f)
+}
+
+// toJSValueAt converts a value nested in the value currently being converted, with seg describing how it is reached.
+// It returns an error wrapping ErrMaxDepth instead if the value is nested deeper than the limit set with SetMaxDepth.
+func (e *encoder) toJSValueAt(seg pathSegment, x interface{}) (js.Value, error) {
+	e.path = append(e.path, seg)
+	defer func() {
+		e.path = e.path[:len(e.path)-1]
+	}()
+
+	if x != nil && e.config.maxDepth > 0 && len(e.path) > e.config.maxDepth {
+		return js.Value{}, e.errorf(reflect.ValueOf(x), ErrMaxDepth)
+	}
+	return e.toJSValue(x)
+}
+
+// interfaceOf returns x.Interface(), or x itself if it was obtained through an unexported struct field, in which case
+// calling Interface would panic. toJSValue converts such a reflect.Value according to its reflect.Kind.
+func interfaceOf(x reflect.Value) interface{} {
+	if !x.CanInterface() {
+		return x
+	}
+	return x.Interface()
+}
+
+// remember records the JS value converted from the Go value identified by key, so that later occurrences of the Go
+// value refer to it instead of being converted again.
+func (e *encoder) remember(key visitKey, value js.Value) {
+	if e.visited == nil {
+		e.visited = make(map[visitKey]js.Value)
+	}
+	e.visited[key] = value
+}
+
+// record records the provided object or array created by the conversion if it is to be frozen, and returns it.
+func (e *encoder) record(obj js.Value) js.Value {
+	if e.freeze {
+		e.created = append(e.created, obj)
+	}
+	return obj
+}
+
+// errorf returns a ConversionError for the provided value at the current path.
+func (e *encoder) errorf(x reflect.Value, err error) error {
+	return ConversionError{
+		Path: e.pathString(),
+		Root: e.root,
+		Type: x.Type(),
+		Kind: x.Kind(),
+		Err:  err,
+	}
+}
+
+// pathString formats the current path, such as "Addresses[2].Coordinates".
+func (e *encoder) pathString() string {
+	var b strings.Builder
+	for _, seg := range e.path {
+		switch {
+		case seg.field != "":
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(seg.field)
+		case seg.key.IsValid():
+			fmt.Fprintf(&b, "[%v]", seg.key)
+		default:
+			fmt.Fprintf(&b, "[%d]", seg.index)
+		}
 	}
+	return b.String()
 }
 
 // toJSArray converts the provided array or slice to a JS array.
-func toJSArray(x reflect.Value) js.Value {
-	arrayConstructor, err := Global().Get("Array")
+func (e *encoder) toJSArray(x reflect.Value) (js.Value, error) {
+	arrayConstructor, err := constructor("Array")
 	if err != nil {
-		panic("Array constructor not found")
+		return js.Value{}, e.errorf(x, err)
+	}
+
+	array := e.record(arrayConstructor.New())
+	if x.Kind() == reflect.Slice && x.Len() > 0 {
+		key := visitKey{ptr: x.Pointer(), typ: x.Type(), length: x.Len()}
+		if visited, ok := e.visited[key]; ok {
+			return visited, nil
+		}
+		e.remember(key, array)
+	}
+
+	// The elements of a slice of plain structs, such as a list of rows, are all converted with the same fields.
+	if fields, ok := e.plainStructFields(x.Type().Elem()); ok {
+		for i := 0; i < x.Len(); i++ {
+			// Like toJSValue, convert a copy of the element rather than the element itself, so that the methods
+			// attached to its JS object are the same.
+			elem := x.Index(i)
+			if elem.CanInterface() {
+				elem = reflect.ValueOf(elem.Interface())
+			}
+			value, err := e.structToJSObjectAt(pathSegment{index: i}, elem, fields)
+			if err != nil {
+				return js.Value{}, err
+			}
+			array.SetIndex(i, value)
+		}
+		return array, nil
 	}
 
-	array := arrayConstructor.New()
 	for i := 0; i < x.Len(); i++ {
-		array.SetIndex(i, ToJSValue(x.Index(i).Interface()))
+		value, err := e.toJSValueAt(pathSegment{index: i}, interfaceOf(x.Index(i)))
+		if err != nil {
+			return js.Value{}, err
+		}
+		array.SetIndex(i, value)
+	}
+
+	return array, nil
+}
+
+var (
+	wrapperType       = reflect.TypeOf((*Wrapper)(nil)).Elem()
+	jsMarshalerType   = reflect.TypeOf((*JSMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// specialStructTypes are the struct types that specialToJSValue or reflectToJSValue convert specially without them
+// implementing one of the interfaces checked by plainStructFields.
+var specialStructTypes = map[reflect.Type]bool{
+	jsValueType:                       true,
+	reflect.TypeOf(big.Int{}):         true,
+	reflect.TypeOf(big.Float{}):       true,
+	reflect.TypeOf(time.Time{}):       true,
+	reflect.TypeOf(netip.Addr{}):      true,
+	syncMapType:                       true,
+	reflect.TypeOf(bytes.Buffer{}):    true,
+	reflect.TypeOf(strings.Builder{}): true,
+}
+
+// implementsMarshaler reports whether t implements one of the interfaces that specialToJSValue converts with the
+// methods of the value.
+func implementsMarshaler(t reflect.Type) bool {
+	for _, iface := range []reflect.Type{wrapperType, jsMarshalerType, jsonMarshalerType, textMarshalerType, errorType} {
+		if t.Implements(iface) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasCustomConversion reports whether the values of type t are converted by their own methods or by a registered
+// converter rather than according to their reflect.Kind, so that containers of t cannot convert them in bulk.
+func hasCustomConversion(t reflect.Type) bool {
+	if implementsMarshaler(t) {
+		return true
+	}
+	_, ok := lookupConverter(t)
+	return ok
+}
+
+// typedArrayElem reports whether the elements of type t of a slice or an array of numbers can be copied into a typed
+// array, which holds their numeric value, rather than each being converted like ToJSValue converts it.
+func (e *encoder) typedArrayElem(t reflect.Type) bool {
+	if e.config.stringerEnums && isStringerEnum(t) {
+		// The elements are converted into strings, which typed arrays cannot hold.
+		return false
+	}
+	if (t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64) && e.config.nonFiniteFloats != NonFiniteAsNumber {
+		// NaN and infinities may be converted into strings or null, which typed arrays cannot hold either.
+		return false
+	}
+	return !hasCustomConversion(t)
+}
+
+// plainStructFields returns the fields of t if it is a struct type whose values are all converted by
+// structToJSObject, so that the elements of a slice of t can skip the dispatch of toJSValue.
+func (e *encoder) plainStructFields(t reflect.Type) ([]structField, bool) {
+	if t.Kind() != reflect.Struct || specialStructTypes[t] || hasCustomConversion(t) {
+		return nil, false
+	}
+	return cachedStructFields(t, e.config.includePrivate), true
+}
+
+// sliceConverter converts a slice of the type it was compiled for into a JS array.
+type sliceConverter func(e *encoder, x reflect.Value) (js.Value, error)
+
+// sliceConverters maps slice types to their sliceConverter compiled by compileSliceConverter, which is nil for types it
+// does not handle.
+var sliceConverters sync.Map
+
+// cachedSliceConverter is like compileSliceConverter but only compiles the converter of each slice type once, so that
+// the following conversions of the type skip the dispatch of toJSValue for each element.
+func cachedSliceConverter(t reflect.Type) sliceConverter {
+	if convert, ok := sliceConverters.Load(t); ok {
+		return convert.(sliceConverter)
+	}
+	convert, _ := sliceConverters.LoadOrStore(t, compileSliceConverter(t))
+	return convert.(sliceConverter)
+}
+
+// compileSliceConverter returns a converter for the provided slice type if its elements are strings, bools, integers or
+// plain structs that are not special in any way, such as []int64, []uint, a named []string or a slice of rows, which
+// are not converted into typed arrays and have no fast path in specialToJSValue. The converter reads basic elements
+// directly with the reflect.Value accessor of their kind, like basicSliceToJSArray does for the basic slice types, and
+// converts struct elements with the cached fields of their type. It returns nil for other types.
+//
+// The converter does not depend on the config, so the caller has to check that the elements are not converted
+// differently by it, such as stringer enums, or by a converter registered after the compilation.
+func compileSliceConverter(t reflect.Type) sliceConverter {
+	elemType := t.Elem()
+	if implementsMarshaler(elemType) || elemType == durationType || specialStructTypes[elemType] {
+		return nil
+	}
+
+	if elemType.Kind() == reflect.Struct {
+		return func(e *encoder, x reflect.Value) (js.Value, error) {
+			fields := cachedStructFields(elemType, e.config.includePrivate)
+			return e.basicSliceToJSArray(x.Interface(), x.UnsafePointer(), x.Len(), func(i int) (js.Value, error) {
+				// Like toJSValue, convert a copy of the element rather than the element itself, so that the methods
+				// attached to its JS object are the same.
+				return e.structToJSObjectAt(pathSegment{index: i}, reflect.ValueOf(x.Index(i).Interface()), fields)
+			})
+		}
+	}
+
+	var elem func(e *encoder, v reflect.Value) (js.Value, error)
+	switch elemType.Kind() {
+	case reflect.String:
+		elem = func(e *encoder, v reflect.Value) (js.Value, error) {
+			return js.ValueOf(v.String()), nil
+		}
+	case reflect.Bool:
+		elem = func(e *encoder, v reflect.Value) (js.Value, error) {
+			return js.ValueOf(v.Bool()), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		elem = func(e *encoder, v reflect.Value) (js.Value, error) {
+			return e.intToJSValue(v, v.Int())
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		elem = func(e *encoder, v reflect.Value) (js.Value, error) {
+			return e.uintToJSValue(v, v.Uint())
+		}
+	default:
+		return nil
+	}
+
+	return func(e *encoder, x reflect.Value) (js.Value, error) {
+		return e.basicSliceToJSArray(x.Interface(), x.UnsafePointer(), x.Len(), func(i int) (js.Value, error) {
+			return elem(e, x.Index(i))
+		})
+	}
+}
+
+// hasElemConverter reports whether a converter is registered for the element type of the slice x, whose elements then
+// have to be converted by toJSValue one by one.
+func hasElemConverter(x interface{}) bool {
+	_, ok := lookupConverter(reflect.TypeOf(x).Elem())
+	return ok
+}
+
+// basicSliceToJSArray converts the slice x of n basic values or plain structs, whose first element is at ptr, into a JS
+// array like toJSArray, with elem converting each element without the dispatch of toJSValue.
+func (e *encoder) basicSliceToJSArray(x interface{}, ptr unsafe.Pointer, n int,
+	elem func(i int) (js.Value, error)) (js.Value, error) {
+	if ptr == nil && e.config.nilCollectionsAsNull {
+		return js.Null(), nil
+	}
+
+	arrayConstructor, err := constructor("Array")
+	if err != nil {
+		return js.Value{}, e.errorf(reflect.ValueOf(x), err)
+	}
+
+	array := e.record(arrayConstructor.New())
+	if n > 0 {
+		key := visitKey{ptr: uintptr(ptr), typ: reflect.TypeOf(x), length: n}
+		if visited, ok := e.visited[key]; ok {
+			return visited, nil
+		}
+		e.remember(key, array)
 	}
 
-	return array
+	if n > 0 && e.config.maxDepth > 0 && len(e.path) >= e.config.maxDepth {
+		// The elements are nested too deeply, which toJSValueAt reports.
+		return e.toJSValueAt(pathSegment{index: 0}, reflect.ValueOf(x).Index(0).Interface())
+	}
+	for i := 0; i < n; i++ {
+		value, err := elem(i)
+		if err != nil {
+			return js.Value{}, err
+		}
+		array.SetIndex(i, value)
+	}
+	return array, nil
 }
 
-// mapToJSObject converts the provided map to a JS object.
-func mapToJSObject(x reflect.Value) js.Value {
-	objectConstructor, err := Global().Get("Object")
+// toJSUint8Array copies the provided bytes of x into a new JS Uint8Array.
+func (e *encoder) toJSUint8Array(x reflect.Value, b []byte) (js.Value, error) {
+	uint8ArrayConstructor, err := constructor("Uint8Array")
 	if err != nil {
-		panic("Object constructor not found")
+		return js.Value{}, e.errorf(x, err)
 	}
 
-	obj := objectConstructor.New()
-	iter := x.MapRange()
-	for iter.Next() {
-		key := iter.Key()
-		value := iter.Value().Interface()
-		switch key := key.Interface().(type) {
-		case int:
-			obj.SetIndex(key, ToJSValue(value))
-		case int8:
-			obj.SetIndex(int(key), ToJSValue(value))
-		case int16:
-			obj.SetIndex(int(key), ToJSValue(value))
-		case int32:
-			obj.SetIndex(int(key), ToJSValue(value))
-		case int64:
-			obj.SetIndex(int(key), ToJSValue(value))
-		case uint:
-			obj.SetIndex(int(key), ToJSValue(value))
-		case uint8:
-			obj.SetIndex(int(key), ToJSValue(value))
-		case uint16:
-			obj.SetIndex(int(key), ToJSValue(value))
-		case uint32:
-			obj.SetIndex(int(key), ToJSValue(value))
-		case uint64:
-			obj.SetIndex(int(key), ToJSValue(value))
-		case uintptr:
-			obj.SetIndex(int(key), ToJSValue(value))
+	array := uint8ArrayConstructor.New(len(b))
+	js.CopyBytesToJS(array, b)
+	return array, nil
+}
+
+// arrayBytes returns the contents of the provided byte array, which is only viewed as a slice if it is addressable, and
+// copied otherwise as is the case for arrays passed by value.
+func arrayBytes(x reflect.Value) []byte {
+	if x.CanAddr() {
+		return x.Slice(0, x.Len()).Bytes()
+	}
+
+	b := make([]byte, x.Len())
+	for i := range b {
+		b[i] = byte(x.Index(i).Uint())
+	}
+	return b
+}
+
+// typedArrayConstructors maps fixed-width numeric kinds to the name of their JS typed array constructor. Slices of int,
+// uint, int64 and uint64 are converted into plain arrays instead, as the width of int and uint is not fixed and a
+// 64-bit typed array would hold BigInts instead of numbers.
+var typedArrayConstructors = map[reflect.Kind]string{
+	reflect.Int8:    "Int8Array",
+	reflect.Int16:   "Int16Array",
+	reflect.Int32:   "Int32Array",
+	reflect.Uint16:  "Uint16Array",
+	reflect.Uint32:  "Uint32Array",
+	reflect.Float32: "Float32Array",
+	reflect.Float64: "Float64Array",
+}
+
+// toJSTypedArray copies the provided numeric slice into a new JS typed array created by the named constructor.
+// The slice's backing memory is reinterpreted as bytes so the copy is done in one go instead of element by element.
+func (e *encoder) toJSTypedArray(x reflect.Value, constructorName string) (js.Value, error) {
+	typedArrayConstructor, err := constructor(constructorName)
+	if err != nil {
+		return js.Value{}, e.errorf(x, err)
+	}
+
+	array := typedArrayConstructor.New(x.Len())
+	if x.Len() == 0 {
+		return array, nil
+	}
+
+	uint8ArrayConstructor, err := constructor("Uint8Array")
+	if err != nil {
+		return js.Value{}, e.errorf(x, err)
+	}
+
+	view := uint8ArrayConstructor.New(array.Get("buffer"), array.Get("byteOffset"), array.Get("byteLength"))
+	js.CopyBytesToJS(view, unsafe.Slice((*byte)(x.UnsafePointer()), x.Len()*int(x.Type().Elem().Size())))
+	return array, nil
+}
+
+// mapToJSObject converts the provided map to a JS object, or to a JS Map if requested by the config.
+// Keys implementing encoding.TextMarshaler or fmt.Stringer, in order of preference, are converted into their text form.
+// Integer and float keys are converted into their decimal form, bool keys into "true" or "false" and time.Time keys
+// into RFC 3339 strings, as JS object keys are always strings.
+func (e *encoder) mapToJSObject(x reflect.Value) (js.Value, error) {
+	if e.config.jsMaps {
+		return e.mapToJSMap(x)
+	}
+
+	objectConstructor, err := constructor("Object")
+	if err != nil {
+		return js.Value{}, e.errorf(x, err)
+	}
+
+	obj := e.record(objectConstructor.New())
+	if !x.IsNil() {
+		key := visitKey{ptr: x.Pointer(), typ: x.Type()}
+		if visited, ok := e.visited[key]; ok {
+			return visited, nil
+		}
+		e.remember(key, obj)
+	}
+
+	err = e.rangeMap(x, func(key, elem reflect.Value) error {
+		value, err := e.toJSValueAt(pathSegment{key: key}, interfaceOf(elem))
+		if err != nil {
+			return err
+		}
+
+		name, err := mapKeyString(key)
+		if err != nil {
+			return e.errorf(x, err)
+		}
+		obj.Set(name, value)
+		return nil
+	})
+	if err != nil {
+		return js.Value{}, err
+	}
+
+	return obj, nil
+}
+
+// rangeMap calls fn with every key and value of the provided map until it returns an error, in the order of their
+// keys if SetSortedMapKeys is enabled and in the iteration order of the map otherwise.
+func (e *encoder) rangeMap(x reflect.Value, fn func(key, value reflect.Value) error) error {
+	if !e.config.sortedMapKeys {
+		iter := x.MapRange()
+		for iter.Next() {
+			if err := fn(iter.Key(), iter.Value()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	keys := x.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return mapKeyLess(keys[i], keys[j])
+	})
+	for _, key := range keys {
+		if err := fn(key, x.MapIndex(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapKeyLess reports whether the map key a sorts before b: numerically for numbers, lexicographically for strings,
+// false before true for bools, chronologically for time.Time, and lexicographically by their fmt.Sprint form otherwise.
+func mapKeyLess(a, b reflect.Value) bool {
+	if a.Type() == timeType && a.CanInterface() {
+		return a.Interface().(time.Time).Before(b.Interface().(time.Time))
+	}
+	switch a.Kind() {
+	case reflect.String:
+		return a.String() < b.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.Bool:
+		return !a.Bool() && b.Bool()
+	}
+	return fmt.Sprint(interfaceOf(a)) < fmt.Sprint(interfaceOf(b))
+}
+
+// mapKeyString returns the name of the JS property for the provided map key.
+// JS object keys are always strings, so integer keys are converted into their decimal form, without going through int
+// so that int64 and uint64 keys keep every bit, and bool keys into "true" and "false". Float keys are converted into
+// the shortest decimal form that round-trips, never in scientific notation, so 1e21 becomes "1000000000000000000000"
+// rather than "1e+21" as it would with String in JS; infinities become "Infinity" and "-Infinity" and negative zero "0"
+// like in JS. time.Time keys are formatted with RFC 3339, like Date.prototype.toISOString but keeping the time zone and
+// the nanoseconds, without going through MarshalText, which fails for years outside of [0, 9999].
+func mapKeyString(key reflect.Value) (string, error) {
+	// The keys of a map[interface{}]V hold values of any type, converted like keys of that type.
+	if key.Kind() == reflect.Interface {
+		if key.IsNil() {
+			return "", ErrUnsupportedMapKey
+		}
+		key = key.Elem()
+	}
+
+	// The methods of a key obtained through an unexported struct field cannot be called, and the reflect.Value that
+	// interfaceOf would return for it is a fmt.Stringer itself, so it is only converted according to its kind.
+	if key.CanInterface() {
+		switch k := key.Interface().(type) {
 		case string:
-			obj.Set(key, ToJSValue(value))
-		default:
-			panic(fmt.Sprintf("cannot convert %T into a JS value as its key is not a string or an integer",
-				x.Interface()))
+			return k, nil
+		case time.Time:
+			return k.Format(time.RFC3339Nano), nil
+		case encoding.TextMarshaler:
+			text, err := k.MarshalText()
+			if err != nil {
+				return "", fmt.Errorf("marshalling map key: %w", err)
+			}
+			return string(text), nil
+		case fmt.Stringer:
+			return k.String(), nil
 		}
 	}
 
-	return obj
+	switch key.Kind() {
+	case reflect.String:
+		return key.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(key.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(key.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(key.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := key.Float()
+		switch {
+		case math.IsInf(f, 1):
+			return "Infinity", nil
+		case math.IsInf(f, -1):
+			return "-Infinity", nil
+		case f == 0:
+			return "0", nil
+		}
+		return strconv.FormatFloat(f, 'f', -1, key.Type().Bits()), nil
+	}
+	return "", ErrUnsupportedMapKey
+}
+
+var syncMapType = reflect.TypeOf(sync.Map{})
+
+// syncMapToJSObject converts the provided sync.Map to a JS object, or to a JS Map if requested by the config,
+// converting its keys and values like mapToJSObject does for a map. The entries are the ones seen by sync.Map.Range.
+func (e *encoder) syncMapToJSObject(m *sync.Map) (js.Value, error) {
+	x := reflect.ValueOf(m)
+
+	ctorName := "Object"
+	if e.config.jsMaps {
+		ctorName = "Map"
+	}
+	ctor, err := constructor(ctorName)
+	if err != nil {
+		return js.Value{}, e.errorf(x, err)
+	}
+
+	obj := e.record(ctor.New())
+	key := visitKey{ptr: x.Pointer(), typ: x.Type()}
+	if visited, ok := e.visited[key]; ok {
+		return visited, nil
+	}
+	e.remember(key, obj)
+
+	var rangeErr error
+	m.Range(func(k, v interface{}) bool {
+		if k == nil {
+			rangeErr = e.errorf(x, ErrUnsupportedMapKey)
+			return false
+		}
+
+		seg := pathSegment{key: reflect.ValueOf(k)}
+		value, err := e.toJSValueAt(seg, v)
+		if err != nil {
+			rangeErr = err
+			return false
+		}
+
+		if e.config.jsMaps {
+			jsKey, err := e.toJSValueAt(seg, k)
+			if err != nil {
+				rangeErr = err
+				return false
+			}
+			obj.Call("set", jsKey, value)
+			return true
+		}
+
+		name, err := mapKeyString(seg.key)
+		if err != nil {
+			rangeErr = e.errorf(x, err)
+			return false
+		}
+		obj.Set(name, value)
+		return true
+	})
+	if rangeErr != nil {
+		return js.Value{}, rangeErr
+	}
+	return obj, nil
+}
+
+// mapToJSMap converts the provided map to a JS Map.
+func (e *encoder) mapToJSMap(x reflect.Value) (js.Value, error) {
+	mapConstructor, err := constructor("Map")
+	if err != nil {
+		return js.Value{}, e.errorf(x, err)
+	}
+
+	m := e.record(mapConstructor.New())
+	if !x.IsNil() {
+		key := visitKey{ptr: x.Pointer(), typ: x.Type()}
+		if visited, ok := e.visited[key]; ok {
+			return visited, nil
+		}
+		e.remember(key, m)
+	}
+
+	err = e.rangeMap(x, func(key, elem reflect.Value) error {
+		jsKey, err := e.toJSValueAt(pathSegment{key: key}, interfaceOf(key))
+		if err != nil {
+			return err
+		}
+		value, err := e.toJSValueAt(pathSegment{key: key}, interfaceOf(elem))
+		if err != nil {
+			return err
+		}
+		m.Call("set", jsKey, value)
+		return nil
+	})
+	if err != nil {
+		return js.Value{}, err
+	}
+
+	return m, nil
 }
 
 // structToJSObject converts a struct to a JS object.
-func structToJSObject(x reflect.Value) js.Value {
-	objectConstructor, err := Global().Get("Object")
+//
+// The JS property of a field is named after the field, as converted by the NameStrategy set with SetNameStrategy,
+// unless it is renamed with a wasm struct tag such as `wasm:"name"`. A field tagged `wasm:"-"` is skipped, and a field
+// with the omitempty option such as `wasm:"name,omitempty"` or `wasm:",omitempty"` is skipped when it holds the zero
+// value of its type.
+// An integer or float field with the string option such as `wasm:"id,string"` is converted into a JS string holding its
+// decimal form, so that integers beyond 2^53 are not corrupted. JS code receiving it has to parse the string itself.
+// A time.Time field with the format option is converted into a string formatted with the option as layout, such as
+// `wasm:"birthday,format=2006-01-02"`, or into a number of seconds or milliseconds since the Unix epoch with
+// `wasm:"ts,format=unix"` and `wasm:"ts,format=unixmilli"`. As options are separated by commas, layouts cannot contain
+// one.
+//
+// The fields of an anonymous embedded struct are promoted to the JS object like encoding/json does, with the fields of
+// the outer struct taking precedence over promoted fields of the same name. The same goes for an embedded pointer to a
+// struct, whose fields are omitted when it is nil.
+//
+// Unexported fields are skipped unless IncludePrivate is enabled.
+//
+// The JS object is an instance of the class registered for the struct type with RegisterClass, if any.
+//
+// Exported methods are attached as functions named after the method, unless renamed or hidden by a JSMethodNamer
+// implementation.
+func (e *encoder) structToJSObject(x reflect.Value) (js.Value, error) {
+	return e.structFieldsToJSObject(x, cachedStructFields(x.Type(), e.config.includePrivate))
+}
+
+// structToJSObjectAt is like toJSValueAt for the struct x, converting it with the provided fields of its type.
+func (e *encoder) structToJSObjectAt(seg pathSegment, x reflect.Value, fields []structField) (js.Value, error) {
+	e.path = append(e.path, seg)
+	defer func() {
+		e.path = e.path[:len(e.path)-1]
+	}()
+
+	if e.config.maxDepth > 0 && len(e.path) > e.config.maxDepth {
+		return js.Value{}, e.errorf(x, ErrMaxDepth)
+	}
+	return e.structFieldsToJSObject(x, fields)
+}
+
+// structFieldsToJSObject is structToJSObject with the fields of the type of x, as returned by cachedStructFields.
+func (e *encoder) structFieldsToJSObject(x reflect.Value, fields []structField) (js.Value, error) {
+	objectConstructor, err := constructor("Object")
 	if err != nil {
-		panic("Object constructor not found")
+		return js.Value{}, e.errorf(x, err)
 	}
 
 	obj := objectConstructor.New()
+	if ctor, ok := lookupClass(x.Type()); ok {
+		obj = objectConstructor.Call("create", ctor.Get("prototype"))
+	}
+	e.record(obj)
+	if x.CanAddr() {
+		e.remember(visitKey{ptr: x.Addr().Pointer(), typ: x.Type()}, obj)
+	}
 
+	// Unexported fields can only be read through the address of the struct, so a struct that is not addressable is
+	// copied to read them.
+	values := x
 	structType := x.Type()
-	for i := 0; i < structType.NumField(); i++ {
-		field := structType.Field(i)
-		if field.PkgPath != "" {
+	for _, field := range fields {
+		if field.private && !values.CanAddr() {
+			if !x.CanInterface() {
+				// The struct was itself obtained through an unexported field and cannot be copied.
+				continue
+			}
+			values = reflect.New(structType).Elem()
+			values.Set(x)
+		}
+
+		fieldValue, err := values.FieldByIndexErr(field.index)
+		if err != nil {
+			// The field is promoted from a nil embedded pointer, so the struct does not have it.
+			continue
+		}
+		if field.private {
+			fieldValue = reflect.NewAt(fieldValue.Type(), unsafe.Pointer(fieldValue.UnsafeAddr())).Elem()
+		}
+		if field.omitEmpty && fieldValue.IsZero() {
+			continue
+		}
+		if e.config.omitNilFuncsAndInterfaces && fieldValue.IsZero() &&
+			(fieldValue.Kind() == reflect.Func || fieldValue.Kind() == reflect.Interface) {
 			continue
 		}
 
-		name := field.Name
-		if tagName, ok := field.Tag.Lookup("wasm"); ok {
-			if tagName == "-" {
+		name := e.config.jsName(field)
+		if field.asString {
+			if str, ok := formatNumber(fieldValue); ok {
+				obj.Set(name, str)
 				continue
 			}
-			name = tagName
+		}
+		if t, ok := interfaceOf(fieldValue).(time.Time); ok && field.format != "" {
+			if t.IsZero() && e.config.zeroTimesAsNull {
+				obj.Set(name, js.Null())
+			} else {
+				obj.Set(name, formatTime(t, field.format))
+			}
+			continue
 		}
 
-		obj.Set(name, ToJSValue(x.Field(i).Interface()))
+		value, err := e.toJSValueAt(pathSegment{field: field.goName}, interfaceOf(fieldValue))
+		if err != nil {
+			return js.Value{}, err
+		}
+		obj.Set(name, value)
 	}
 
-	for i := 0; i < structType.NumMethod(); i++ {
-		method := structType.Method(i)
-		obj.Set(method.Name, toJSFunc(x.Method(i)))
+	// The method set of a pointer includes the methods with a value receiver, so an addressable struct only needs the
+	// methods of its pointer to have every method attached exactly once.
+	receiver := x
+	if x.CanAddr() {
+		receiver = x.Addr()
+	}
+	if !receiver.CanInterface() {
+		// The methods of a struct obtained through an unexported field cannot be called.
+		if e.config.strictStructs && len(fields) == 0 && x.NumField() != 0 {
+			return js.Value{}, e.errorf(x, ErrEmptyStruct)
+		}
+		return obj, nil
+	}
+	methods := jsMethods(receiver)
+	if e.config.strictStructs && len(fields) == 0 && len(methods) == 0 && x.NumField() != 0 {
+		return js.Value{}, e.errorf(x, ErrEmptyStruct)
+	}
+	if e.config.lazyMethods && len(methods) != 0 {
+		if err := e.defineLazyMethods(obj, receiver, methods); err != nil {
+			return js.Value{}, e.errorf(x, err)
+		}
+		return obj, nil
+	}
+	for _, method := range methods {
+		obj.Set(method.name, e.toJSFunc(receiver.Method(method.index), methodName(receiver, method.index)))
 	}
 
-	if x.CanAddr() {
-		structPtr := reflect.PointerTo(structType)
-		for i := 0; i < structPtr.NumMethod(); i++ {
-			method := structPtr.Method(i)
-			obj.Set(method.Name, toJSFunc(x.Addr().Method(i)))
+	return obj, nil
+}
+
+// methodName returns the name of the method of receiver with the provided index, for ArgumentError.
+func methodName(receiver reflect.Value, index int) string {
+	return receiver.Type().String() + "." + receiver.Type().Method(index).Name
+}
+
+// jsMethod is a method of a struct that is attached to its JS object.
+type jsMethod struct {
+	name  string // Name of the JS property.
+	index int    // Index of the method for reflect.Value.Method.
+}
+
+// jsMethods returns the methods of receiver that are attached to its JS object, named as its JSMethodNamer
+// implementation says if it has one.
+func jsMethods(receiver reflect.Value) []jsMethod {
+	var names map[string]string
+	if namer, ok := receiver.Interface().(JSMethodNamer); ok {
+		names = namer.JSMethodNames()
+	}
+
+	methods := make([]jsMethod, 0, receiver.NumMethod())
+	for i := 0; i < receiver.NumMethod(); i++ {
+		name := receiver.Type().Method(i).Name
+		if names != nil {
+			if name == "JSMethodNames" {
+				continue
+			}
+			if jsName, ok := names[name]; ok {
+				if jsName == "" {
+					continue
+				}
+				name = jsName
+			}
 		}
+		methods = append(methods, jsMethod{name: name, index: i})
 	}
+	return methods
+}
 
-	return obj
+// formatTime converts the provided time into a JS value according to the format option of a struct field: a number of
+// seconds or milliseconds since the Unix epoch for "unix" and "unixmilli", or a string formatted with the option as a
+// layout of time.Time.Format otherwise.
+func formatTime(t time.Time, format string) js.Value {
+	switch format {
+	case "unix":
+		return js.ValueOf(t.Unix())
+	case "unixmilli":
+		return js.ValueOf(t.UnixMilli())
+	default:
+		return js.ValueOf(t.Format(format))
+	}
+}
+
+// formatNumber formats the provided integer or float as a decimal string.
+// It returns false if the value is of any other kind.
+func formatNumber(x reflect.Value) (string, bool) {
+	switch x.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(x.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(x.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(x.Float(), 'f', -1, x.Type().Bits()), true
+	default:
+		return "", false
+	}
 }
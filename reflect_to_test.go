@@ -0,0 +1,1398 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall/js"
+	"testing"
+	"time"
+)
+
+func TestToJSValueInterfaceMapKeys(t *testing.T) {
+	m := map[interface{}]string{
+		"s":      "string",
+		1:        "int",
+		uint8(2): "uint8",
+		true:     "bool",
+		1.5:      "float",
+	}
+	value, err := ToJSValueWithErr(m, WithSortedMapKeys(true))
+	if err != nil {
+		t.Fatalf("ToJSValueWithErr() error = %v", err)
+	}
+
+	for key, want := range map[string]string{"s": "string", "1": "int", "2": "uint8", "true": "bool", "1.5": "float"} {
+		if got := value.Get(key); got.String() != want {
+			t.Errorf("property %q = %v, want %q", key, got, want)
+		}
+	}
+}
+
+func TestToJSValueBoolMapKeys(t *testing.T) {
+	value := ToJSValue(map[bool]string{true: "yes", false: "no"})
+	for key, want := range map[string]string{"true": "yes", "false": "no"} {
+		if got := value.Get(key); got.String() != want {
+			t.Errorf("property %q = %v, want %q", key, got, want)
+		}
+	}
+	sorted := ToJSValueWith(map[bool]int{true: 1, false: 0}, WithSortedMapKeys(true))
+	if got := jsonString(t, sorted); got != `{"false":0,"true":1}` {
+		t.Errorf("ToJSValueWith(WithSortedMapKeys) = %s, want {\"false\":0,\"true\":1}", got)
+	}
+}
+
+func TestToJSValueNilInterfaceMapKey(t *testing.T) {
+	_, err := ToJSValueErr(map[interface{}]string{nil: "nil"})
+	if !errors.Is(err, ErrUnsupportedMapKey) {
+		t.Errorf("ToJSValueErr() error = %v, want ErrUnsupportedMapKey", err)
+	}
+}
+
+func TestToJSValueUnexportedMapKeys(t *testing.T) {
+	type withMap struct {
+		m map[int]string
+	}
+	value, err := ToJSValueWithErr(withMap{m: map[int]string{1: "a"}}, WithPrivateFields(true))
+	if err != nil {
+		t.Fatalf("ToJSValueWithErr() error = %v", err)
+	}
+	if got := jsonString(t, value); got != `{"m":{"1":"a"}}` {
+		t.Errorf("ToJSValueWithErr() = %s, want {\"m\":{\"1\":\"a\"}}", got)
+	}
+
+	// A reflect.Value read through an unexported field holds keys that cannot be interfaced.
+	value, err = ToJSValueErr(reflect.ValueOf(withMap{m: map[int]string{2: "b"}}).Field(0))
+	if err != nil {
+		t.Fatalf("ToJSValueErr() error = %v", err)
+	}
+	if got := jsonString(t, value); got != `{"2":"b"}` {
+		t.Errorf("ToJSValueErr() = %s, want {\"2\":\"b\"}", got)
+	}
+}
+
+func TestToJSValueUnexportedFuncsAndChans(t *testing.T) {
+	type unexported struct {
+		c  chan int
+		f  func()
+		fs map[string]func()
+	}
+	x := reflect.ValueOf(unexported{c: make(chan int), f: func() {}, fs: map[string]func(){"a": func() {}}})
+	for i := 0; i < x.NumField(); i++ {
+		if _, err := ToJSValueErr(x.Field(i)); !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("ToJSValueErr(%s) error = %v, want ErrUnsupportedType", x.Type().Field(i).Name, err)
+		}
+	}
+}
+
+func TestToJSSet(t *testing.T) {
+	set := ToJSSet([]string{"b", "a", "b", "c", "a"})
+	if got := set.Get("size").Int(); got != 3 {
+		t.Errorf("size = %d, want 3", got)
+	}
+	values := js.Global().Get("Array").Call("from", set)
+	if got := jsonString(t, values); got != `["b","a","c"]` {
+		t.Errorf("values = %s, want [\"b\",\"a\",\"c\"]", got)
+	}
+}
+
+func TestToJSSetErrInvalid(t *testing.T) {
+	for _, x := range []interface{}{nil, 1, map[string]int{}} {
+		if _, err := ToJSSetErr(x); !errors.Is(err, ErrUnsupportedType) {
+			t.Errorf("ToJSSetErr(%#v) error = %v, want ErrUnsupportedType", x, err)
+		}
+	}
+}
+
+type testLevel uint8
+
+func (l testLevel) MarshalText() ([]byte, error) {
+	return []byte("L" + strconv.Itoa(int(l))), nil
+}
+
+type testCelsius float64
+
+func (c testCelsius) JSValue() js.Value {
+	return js.ValueOf(strconv.FormatFloat(float64(c), 'f', -1, 64) + "C")
+}
+
+type testConverted int16
+
+func TestToJSValueTypedArrays(t *testing.T) {
+	RegisterConverter(reflect.TypeOf(testConverted(0)), func(x interface{}) js.Value {
+		return js.ValueOf("converted")
+	})
+	t.Cleanup(func() {
+		UnregisterConverter(reflect.TypeOf(testConverted(0)))
+	})
+
+	tests := []struct {
+		name     string
+		x        interface{}
+		wantCtor string
+		want     string
+	}{
+		{"bytes", []byte{1, 2}, "Uint8Array", `{"0":1,"1":2}`},
+		{"byte array", [2]byte{1, 2}, "Uint8Array", `{"0":1,"1":2}`},
+		{"int16", []int16{1, -2}, "Int16Array", `{"0":1,"1":-2}`},
+		{"float64", []float64{1.5}, "Float64Array", `{"0":1.5}`},
+		{"text marshalers", []testLevel{1, 2}, "Array", `["L1","L2"]`},
+		{"array of text marshalers", [2]testLevel{1, 2}, "Array", `["L1","L2"]`},
+		{"wrappers", []testCelsius{21.5}, "Array", `["21.5C"]`},
+		{"registered converter", []testConverted{1, 2}, "Array", `["converted","converted"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := ToJSValue(tt.x)
+			if got := value.Get("constructor").Get("name").String(); got != tt.wantCtor {
+				t.Errorf("constructor = %s, want %s", got, tt.wantCtor)
+			}
+			if got := jsonString(t, value); got != tt.want {
+				t.Errorf("ToJSValue() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+type testBase struct {
+	ID int `wasm:"id"`
+}
+
+type testLeveledBase struct {
+	*testBase
+	Level int
+}
+
+func TestToJSValueEmbeddedFields(t *testing.T) {
+	tests := []struct {
+		name string
+		x    interface{}
+		want string
+	}{
+		{"struct", struct {
+			testBase
+			Name string
+		}{testBase{ID: 1}, "a"}, `{"id":1,"Name":"a"}`},
+		{"pointer to struct", struct {
+			*testBase
+			Name string
+		}{&testBase{ID: 2}, "b"}, `{"id":2,"Name":"b"}`},
+		{"nil pointer to struct", struct {
+			*testBase
+			Name string
+		}{nil, "c"}, `{"Name":"c"}`},
+		{"nested pointers to structs", &struct {
+			*testLeveledBase
+		}{&testLeveledBase{&testBase{ID: 3}, 2}}, `{"id":3,"Level":2}`},
+		{"js.Value", struct {
+			js.Value
+			ID int
+		}{js.ValueOf("hello"), 1}, `{"Value":"hello","ID":1}`},
+		{"bytes.Buffer", struct {
+			bytes.Buffer
+		}{*bytes.NewBufferString("abc")}, `{"Buffer":"abc"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonString(t, ToJSValue(tt.x)); got != tt.want {
+				t.Errorf("ToJSValue() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToJSValueEmbeddedConverted(t *testing.T) {
+	type outer struct {
+		testBase
+		Name string
+	}
+	x := outer{testBase{ID: 1}, "a"}
+	if got := jsonString(t, ToJSValueWith(x, WithPrivateFields(true))); got != `{"id":1,"Name":"a"}` {
+		t.Fatalf("ToJSValueWith() = %s, want {\"id\":1,\"Name\":\"a\"}", got)
+	}
+
+	// Registering a converter for the embedded type stops its fields from being promoted, even though the fields of
+	// outer were already cached. The embedded field is then unexported, as its type is.
+	RegisterConverter(reflect.TypeOf(testBase{}), func(x interface{}) js.Value {
+		return js.ValueOf("converted")
+	})
+	t.Cleanup(func() {
+		UnregisterConverter(reflect.TypeOf(testBase{}))
+	})
+	if got := jsonString(t, ToJSValueWith(x, WithPrivateFields(true))); got != `{"testBase":"converted","Name":"a"}` {
+		t.Errorf("ToJSValueWith() = %s, want {\"testBase\":\"converted\",\"Name\":\"a\"}", got)
+	}
+}
+
+type testWrapper struct{}
+
+func (*testWrapper) JSValue() js.Value {
+	return js.ValueOf("wrapped")
+}
+
+func TestToJSMap(t *testing.T) {
+	type point struct{ X, Y int }
+
+	value := ToJSMap(&point{X: 1, Y: 2})
+	if !value.InstanceOf(js.Global().Get("Map")) {
+		t.Fatalf("ToJSMap() = %v, want a Map", value)
+	}
+	if got := value.Call("get", "Y").Int(); got != 2 {
+		t.Errorf("Y = %d, want 2", got)
+	}
+
+	// Structs converted specially are converted like ToJSValue does instead of into an empty Map.
+	if value := ToJSMap(time.UnixMilli(1000)); !value.InstanceOf(js.Global().Get("Date")) {
+		t.Errorf("ToJSMap(time.Time) = %v, want a Date", value)
+	}
+	if value := ToJSMap(&testWrapper{}); value.String() != "wrapped" {
+		t.Errorf("ToJSMap(Wrapper) = %v, want wrapped", value)
+	}
+}
+
+func TestToJSValueNonFiniteFloatSlices(t *testing.T) {
+	floats := []float64{1, math.NaN(), math.Inf(1), math.Inf(-1)}
+	tests := []struct {
+		mode     NonFiniteFloats
+		wantCtor string
+		want     string
+	}{
+		{NonFiniteAsNumber, "Float64Array", `{"0":1,"1":null,"2":null,"3":null}`},
+		{NonFiniteAsString, "Array", `[1,"NaN","Infinity","-Infinity"]`},
+		{NonFiniteAsNull, "Array", `[1,null,null,null]`},
+	}
+	for _, tt := range tests {
+		value := ToJSValueWith(floats, WithNonFiniteFloats(tt.mode))
+		if got := value.Get("constructor").Get("name").String(); got != tt.wantCtor {
+			t.Errorf("mode %d: constructor = %s, want %s", tt.mode, got, tt.wantCtor)
+		}
+		if got := jsonString(t, value); got != tt.want {
+			t.Errorf("mode %d: ToJSValueWith() = %s, want %s", tt.mode, got, tt.want)
+		}
+	}
+}
+
+type testString string
+
+func TestToJSValueBasicSlicesWithConverter(t *testing.T) {
+	upper := func(x interface{}) js.Value {
+		return js.ValueOf(strings.ToUpper(reflect.ValueOf(x).String()))
+	}
+	RegisterConverter(reflect.TypeOf(""), upper)
+	RegisterConverter(reflect.TypeOf(testString("")), upper)
+	t.Cleanup(func() {
+		UnregisterConverter(reflect.TypeOf(""))
+		UnregisterConverter(reflect.TypeOf(testString("")))
+	})
+
+	if got := jsonString(t, ToJSValue([]string{"a", "b"})); got != `["A","B"]` {
+		t.Errorf("ToJSValue([]string) = %s, want [\"A\",\"B\"]", got)
+	}
+	if got := jsonString(t, ToJSValue([]testString{"c"})); got != `["C"]` {
+		t.Errorf("ToJSValue([]testString) = %s, want [\"C\"]", got)
+	}
+}
+
+type testColor int
+
+func (c testColor) String() string {
+	return [...]string{"red", "green"}[c]
+}
+
+func TestToJSValueCompiledSlices(t *testing.T) {
+	type row struct {
+		ID   int `wasm:"id"`
+		Name string
+	}
+	tests := []struct {
+		name string
+		x    interface{}
+		opts []Option
+		want string
+	}{
+		{"int64", []int64{1, -2}, nil, `[1,-2]`},
+		{"named strings", benchmarkNames{"a", "b"}, nil, `["a","b"]`},
+		{"durations", []time.Duration{time.Second, 1500 * time.Microsecond}, nil, `[1000,1.5]`},
+		{"stringer enums", []testColor{0, 1}, nil, `[0,1]`},
+		{"stringer enums as strings", []testColor{0, 1}, []Option{WithStringerEnums(true)}, `["red","green"]`},
+		{"structs", []row{{ID: 1, Name: "a"}, {ID: 2}}, nil, `[{"id":1,"Name":"a"},{"id":2,"Name":""}]`},
+		{"special structs", []time.Time{time.UnixMilli(0).UTC()}, nil, `["1970-01-01T00:00:00.000Z"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The second conversion uses the cached converter.
+			for i := 0; i < 2; i++ {
+				value, err := ToJSValueWithErr(tt.x, tt.opts...)
+				if err != nil {
+					t.Fatalf("ToJSValueWithErr() error = %v", err)
+				}
+				if got := jsonString(t, value); got != tt.want {
+					t.Errorf("ToJSValueWithErr() = %s, want %s", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkToJSValueStrings(b *testing.B) {
+	strs := make([]string, 1000)
+	values := make([]interface{}, len(strs))
+	for i := range strs {
+		strs[i] = strconv.Itoa(i)
+		values[i] = strs[i]
+	}
+
+	b.Run("[]string", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ToJSValue(strs)
+		}
+	})
+	b.Run("[]interface{}", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ToJSValue(values)
+		}
+	})
+}
+
+func TestToJSValueInterfaceElements(t *testing.T) {
+	type item struct {
+		Name string
+	}
+	n := 2
+	values := []any{1, "a", item{Name: "b"}, &item{Name: "c"}, &n, nil, []any{true}}
+	want := `[1,"a",{"Name":"b"},{"Name":"c"},2,null,[true]]`
+	if got := jsonString(t, ToJSValue(values)); got != want {
+		t.Errorf("ToJSValue() = %s, want %s", got, want)
+	}
+}
+
+func TestToJSValueNilPointerElements(t *testing.T) {
+	one, three := 1, 3
+	ints := []*int{&one, nil, &three}
+
+	value := ToJSValue(ints)
+	if got := value.Length(); got != 3 {
+		t.Errorf("length = %d, want 3", got)
+	}
+	if got := value.Index(1); !got.IsUndefined() {
+		t.Errorf("nil element = %v, want undefined", got)
+	}
+
+	value = ToJSValueWith(ints, WithNilPointersAsNull(true))
+	if got := jsonString(t, value); got != "[1,null,3]" {
+		t.Errorf("ToJSValueWith(WithNilPointersAsNull) = %s, want [1,null,3]", got)
+	}
+}
+
+func TestToJSValueNilPointerFields(t *testing.T) {
+	type record struct {
+		Count *int
+	}
+	typeOf := jsFunc("x", `return "Count" in x ? String(x.Count) : "absent"`)
+	tests := []struct {
+		name    string
+		x       interface{}
+		enabled bool
+		want    string
+	}{
+		{"struct field as undefined", record{}, false, "undefined"},
+		{"struct field as null", record{}, true, "null"},
+		{"map value as undefined", map[string]*int{"Count": nil}, false, "undefined"},
+		{"map value as null", map[string]*int{"Count": nil}, true, "null"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := ToJSValueWith(tt.x, WithNilPointersAsNull(tt.enabled))
+			if got := typeOf.Invoke(value).String(); got != tt.want {
+				t.Errorf("Count = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToJSValueByteArrays(t *testing.T) {
+	type record struct {
+		ID   [16]byte
+		hash [4]byte
+	}
+	id := [16]byte{0: 0xde, 1: 0xad, 15: 0xff}
+	r := record{ID: id, hash: [4]byte{1, 2, 3, 4}}
+	tests := []struct {
+		name string
+		x    interface{}
+		opts []Option
+		path []string
+		want [][]byte
+	}{
+		{"array", id, nil, nil, [][]byte{id[:]}},
+		{"pointer to array", &id, nil, nil, [][]byte{id[:]}},
+		{"fields", r, []Option{WithPrivateFields(true)}, []string{"ID", "hash"}, [][]byte{id[:], {1, 2, 3, 4}}},
+		{"fields of a pointer", &r, []Option{WithPrivateFields(true)}, []string{"ID", "hash"}, [][]byte{id[:], {1, 2, 3, 4}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := ToJSValueWith(tt.x, tt.opts...)
+			for i, want := range tt.want {
+				array := value
+				if tt.path != nil {
+					array = value.Get(tt.path[i])
+				}
+				if got := array.Get("constructor").Get("name").String(); got != "Uint8Array" {
+					t.Errorf("constructor = %s, want Uint8Array", got)
+				}
+				got := make([]byte, array.Length())
+				js.CopyBytesToGo(got, array)
+				if !bytes.Equal(got, want) {
+					t.Errorf("bytes = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+
+	// The Uint8Array holds a copy of the array.
+	value := ToJSValue(&id)
+	id[0] = 0
+	if got := value.Index(0).Int(); got != 0xde {
+		t.Errorf("first byte after changing the array = %#x, want 0xde", got)
+	}
+}
+
+type benchmarkRow struct {
+	ID      int    `wasm:"id"`
+	Name    string `wasm:"name"`
+	Email   string `wasm:"email,omitempty"`
+	Phone   string `wasm:"phone,omitempty"`
+	City    string
+	Country string
+	Age     int
+	Score   float64
+	Active  bool `wasm:"active"`
+	private int
+}
+
+// benchmarkRows returns n rows of 10 fields.
+func benchmarkRows(n int) []benchmarkRow {
+	rows := make([]benchmarkRow, n)
+	for i := range rows {
+		rows[i] = benchmarkRow{
+			ID:      i,
+			Name:    strconv.Itoa(i),
+			Email:   strconv.Itoa(i) + "@example.com",
+			City:    "Jakarta",
+			Country: "ID",
+			Age:     i % 100,
+			Score:   float64(i) / 2,
+			Active:  i%2 == 0,
+		}
+	}
+	return rows
+}
+
+func BenchmarkToJSValueStruct(b *testing.B) {
+	rows := benchmarkRows(10000)
+
+	b.Run("cached fields", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, row := range rows {
+				ToJSValue(row)
+			}
+		}
+	})
+	b.Run("uncached fields", func(b *testing.B) {
+		// The fields of the type are computed again for each row, like they were before being cached.
+		key := fieldCacheKey{typ: reflect.TypeOf(benchmarkRow{})}
+		for i := 0; i < b.N; i++ {
+			for _, row := range rows {
+				fieldCache.Delete(key)
+				ToJSValue(row)
+			}
+		}
+	})
+}
+
+func BenchmarkToJSValueStructs(b *testing.B) {
+	rows := benchmarkRows(1000)
+
+	b.Run("[]benchmarkRow", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ToJSValue(rows)
+		}
+	})
+	b.Run("[]interface{}", func(b *testing.B) {
+		// Each row is boxed like the elements of a []benchmarkRow used to be before being dispatched one by one.
+		values := make([]interface{}, len(rows))
+		for i := 0; i < b.N; i++ {
+			for j := range rows {
+				values[j] = rows[j]
+			}
+			ToJSValue(values)
+		}
+	})
+}
+
+func BenchmarkToJSValueJSValues(b *testing.B) {
+	jsValues := make([]js.Value, 1000)
+	values := make([]interface{}, len(jsValues))
+	for i := range jsValues {
+		jsValues[i] = js.ValueOf(i)
+		values[i] = jsValues[i]
+	}
+
+	b.Run("[]js.Value", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ToJSValue(jsValues)
+		}
+	})
+	b.Run("[]interface{}", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ToJSValue(values)
+		}
+	})
+}
+
+type benchmarkNames []string
+
+func BenchmarkToJSValueCompiledSlices(b *testing.B) {
+	names := make(benchmarkNames, 1000)
+	ids := make([]int64, len(names))
+	for i := range names {
+		names[i] = strconv.Itoa(i)
+		ids[i] = int64(i)
+	}
+
+	for _, slice := range []interface{}{names, ids} {
+		x := reflect.ValueOf(slice)
+		values := make([]interface{}, x.Len())
+		for i := range values {
+			values[i] = x.Index(i).Interface()
+		}
+
+		b.Run(x.Type().String(), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ToJSValue(slice)
+			}
+		})
+		b.Run(x.Type().String()+" as []interface{}", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ToJSValue(values)
+			}
+		})
+	}
+}
+
+func TestToJSValueCycles(t *testing.T) {
+	type node struct {
+		Name string
+		Next *node
+	}
+	n := &node{Name: "a"}
+	n.Next = n
+	value := ToJSValue(n)
+	if !value.Get("Next").Equal(value) {
+		t.Errorf("Next of a self-referential node is not the node itself")
+	}
+
+	m := map[string]interface{}{"name": "m"}
+	m["self"] = m
+	value = ToJSValue(m)
+	if !value.Get("self").Equal(value) {
+		t.Errorf("self of a self-referential map is not the map itself")
+	}
+
+	shared := &node{Name: "shared"}
+	value = ToJSValue([]*node{shared, shared})
+	if !value.Index(0).Equal(value.Index(1)) {
+		t.Errorf("elements pointing to the same node are converted into different objects")
+	}
+}
+
+func TestToJSValueOmitEmpty(t *testing.T) {
+	type record struct {
+		Name    string   `wasm:"name,omitempty"`
+		Count   int      `wasm:"count,omitempty"`
+		Tags    []string `wasm:"tags,omitempty"`
+		Pointer *int     `wasm:",omitempty"`
+		Kept    int      `wasm:"kept"`
+	}
+	n := 0
+	tests := []struct {
+		name string
+		x    record
+		want string
+	}{
+		{"zero values", record{}, `{"kept":0}`},
+		{"set values", record{Name: "a", Count: 1, Tags: []string{"t"}, Pointer: &n, Kept: 2},
+			`{"name":"a","count":1,"tags":["t"],"Pointer":0,"kept":2}`},
+		{"empty non-nil slice", record{Tags: []string{}}, `{"tags":[],"kept":0}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonString(t, ToJSValue(tt.x)); got != tt.want {
+				t.Errorf("ToJSValue() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToJSValueLargeIntegers(t *testing.T) {
+	typeOf := jsFunc("x", "return typeof x")
+	tests := []struct {
+		x        interface{}
+		wantType string
+		want     string
+	}{
+		{int64(math.MaxInt64), "bigint", "9223372036854775807"},
+		{int64(math.MinInt64), "bigint", "-9223372036854775808"},
+		{uint64(math.MaxUint64), "bigint", "18446744073709551615"},
+		{int64(1<<53 - 1), "number", "9007199254740991"},
+		{-(1<<53 - 1), "number", "-9007199254740991"},
+		{int64(1 << 53), "bigint", "9007199254740992"},
+		{[]int{1, math.MaxInt}, "object", "1,9223372036854775807"},
+	}
+	for _, tt := range tests {
+		value := ToJSValue(tt.x)
+		if got := typeOf.Invoke(value).String(); got != tt.wantType {
+			t.Errorf("typeof ToJSValue(%v) = %s, want %s", tt.x, got, tt.wantType)
+		}
+		if got := js.Global().Call("String", value).String(); got != tt.want {
+			t.Errorf("String(ToJSValue(%v)) = %s, want %s", tt.x, got, tt.want)
+		}
+	}
+}
+
+type testTextColor int
+
+func (c testTextColor) String() string {
+	return "stringer"
+}
+
+func (c testTextColor) MarshalText() ([]byte, error) {
+	return []byte("text" + strconv.Itoa(int(c))), nil
+}
+
+func TestToJSValueTextMapKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		x    interface{}
+		want string
+	}{
+		{"fmt.Stringer", map[testColor]int{0: 1, 1: 2}, `{"green":2,"red":1}`},
+		{"encoding.TextMarshaler", map[testLevel]int{1: 1, 2: 2}, `{"L1":1,"L2":2}`},
+		{"both", map[testTextColor]int{1: 1}, `{"text1":1}`},
+		{"interface", map[interface{}]int{testColor(0): 1, testLevel(3): 2}, `{"L3":2,"red":1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := ToJSValueErr(tt.x)
+			if err != nil {
+				t.Fatalf("ToJSValueErr() error = %v", err)
+			}
+			// The keys are sorted in JS so that the result does not depend on the iteration order of the map.
+			sorted := jsFunc("x", "return Object.fromEntries(Object.entries(x).sort())").Invoke(value)
+			if got := jsonString(t, sorted); got != tt.want {
+				t.Errorf("ToJSValueErr() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToJSValueNilCollections(t *testing.T) {
+	type record struct {
+		Ints  []int
+		Names []string
+		Items []interface{}
+		Map   map[string]int
+	}
+	tests := []struct {
+		name    string
+		x       interface{}
+		enabled bool
+		want    string
+	}{
+		{"nil as empty", record{}, false, `{"Ints":[],"Names":[],"Items":[],"Map":{}}`},
+		{"nil as null", record{}, true, `{"Ints":null,"Names":null,"Items":null,"Map":null}`},
+		{"empty as empty", record{Ints: []int{}, Names: []string{}, Items: []interface{}{}, Map: map[string]int{}}, true,
+			`{"Ints":[],"Names":[],"Items":[],"Map":{}}`},
+		{"nil slice", []int(nil), true, `null`},
+		{"nil map", map[string]int(nil), false, `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := ToJSValueWith(tt.x, WithNilCollectionsAsNull(tt.enabled))
+			if got := jsonString(t, value); got != tt.want {
+				t.Errorf("ToJSValueWith() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToJSValueNestedPointers(t *testing.T) {
+	type point struct {
+		X, Y int
+	}
+	p := &point{X: 1, Y: 2}
+	var nilPoint *point
+	var i interface{} = p
+	tests := []struct {
+		name string
+		x    interface{}
+		want string
+	}{
+		{"pointer to pointer", &p, `{"X":1,"Y":2}`},
+		{"pointer to pointer to pointer", func() ***point { pp := &p; return &pp }(), `{"X":1,"Y":2}`},
+		{"pointer to interface", &i, `{"X":1,"Y":2}`},
+		{"pointer to nil pointer", &nilPoint, "undefined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := ToJSValueErr(tt.x)
+			if err != nil {
+				t.Fatalf("ToJSValueErr() error = %v", err)
+			}
+			if value.IsUndefined() {
+				if tt.want != "undefined" {
+					t.Errorf("ToJSValueErr() = undefined, want %s", tt.want)
+				}
+				return
+			}
+			if got := jsonString(t, value); got != tt.want {
+				t.Errorf("ToJSValueErr() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToJSValueSyncMap(t *testing.T) {
+	type cache struct {
+		Entries sync.Map
+	}
+	var c cache
+	c.Entries.Store("name", "a")
+	c.Entries.Store(2, []int{1})
+	c.Entries.Store(testColor(1), true)
+	tests := []struct {
+		name string
+		x    interface{}
+		want string
+	}{
+		{"pointer", &c.Entries, `{"2":[1],"green":true,"name":"a"}`},
+		{"field", &c, `{"Entries":{"2":[1],"green":true,"name":"a"}}`},
+		{"empty", &sync.Map{}, `{}`},
+	}
+	sortKeys := jsFunc("x", `return JSON.parse(JSON.stringify(x, (k, v) =>
+		v && typeof v === "object" && !Array.isArray(v) ? Object.fromEntries(Object.entries(v).sort()) : v))`)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := ToJSValueErr(tt.x)
+			if err != nil {
+				t.Fatalf("ToJSValueErr() error = %v", err)
+			}
+			if got := jsonString(t, sortKeys.Invoke(value)); got != tt.want {
+				t.Errorf("ToJSValueErr() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	var invalid sync.Map
+	invalid.Store(struct{}{}, 1)
+	if _, err := ToJSValueErr(&invalid); !errors.Is(err, ErrUnsupportedMapKey) {
+		t.Errorf("ToJSValueErr() error = %v, want ErrUnsupportedMapKey", err)
+	}
+}
+
+func TestToJSValueTimeFormats(t *testing.T) {
+	type event struct {
+		Birthday time.Time `wasm:"birthday,format=2006-01-02"`
+		Seconds  time.Time `wasm:"ts,format=unix"`
+		Millis   time.Time `wasm:"ms,omitempty,format=unixmilli"`
+	}
+	at := time.Date(2024, time.March, 5, 6, 7, 8, 9000000, time.UTC)
+	tests := []struct {
+		name string
+		x    event
+		opts []Option
+		want string
+	}{
+		{"formats", event{at, at, at}, nil, `{"birthday":"2024-03-05","ts":1709618828,"ms":1709618828009}`},
+		{"zero times", event{}, nil, `{"birthday":"0001-01-01","ts":-62135596800}`},
+		{"zero times as null", event{}, []Option{WithZeroTimesAsNull(true)}, `{"birthday":null,"ts":null}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonString(t, ToJSValueWith(tt.x, tt.opts...)); got != tt.want {
+				t.Errorf("ToJSValueWith() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToJSValueMaxDepth(t *testing.T) {
+	type row struct {
+		ID int
+	}
+	type tree struct {
+		Children []tree
+	}
+	// Each value nests its innermost value 3 levels deep.
+	tests := []struct {
+		name string
+		x    interface{}
+	}{
+		{"maps", map[string]interface{}{"a": map[string]interface{}{"b": map[string]int{"c": 1}}}},
+		{"basic slices", [][][]int{{{1}}}},
+		{"struct slices", [][]row{{{ID: 1}}}},
+		{"structs", tree{Children: []tree{{}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ToJSValueWithErr(tt.x, WithMaxDepth(3)); err != nil {
+				t.Errorf("ToJSValueWithErr(WithMaxDepth(3)) error = %v", err)
+			}
+			if _, err := ToJSValueWithErr(tt.x, WithMaxDepth(2)); !errors.Is(err, ErrMaxDepth) {
+				t.Errorf("ToJSValueWithErr(WithMaxDepth(2)) error = %v, want ErrMaxDepth", err)
+			}
+			if _, err := ToJSValueWithErr(tt.x, WithMaxDepth(0)); err != nil {
+				t.Errorf("ToJSValueWithErr(WithMaxDepth(0)) error = %v", err)
+			}
+		})
+	}
+
+	deep := map[string]interface{}{}
+	for i, m := 0, deep; i <= DefaultMaxDepth; i++ {
+		next := map[string]interface{}{}
+		m["next"] = next
+		m = next
+	}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("ToJSValue() of a value nested beyond DefaultMaxDepth did not panic")
+		}
+	}()
+	ToJSValue(deep)
+}
+
+func TestToJSValueBigNumbers(t *testing.T) {
+	digits := strings.Repeat("1234567890", 10)
+	i, _ := new(big.Int).SetString(digits, 10)
+	negative := new(big.Int).Neg(i)
+	f, _ := new(big.Float).SetPrec(200).SetString("0.1")
+	typeOf := jsFunc("x", "return typeof x")
+	tests := []struct {
+		name     string
+		x        interface{}
+		opts     []Option
+		wantType string
+		want     string
+	}{
+		{"100-digit big.Int", i, nil, "bigint", digits},
+		{"negative big.Int", negative, nil, "bigint", "-" + digits},
+		{"big.Int value", *big.NewInt(42), nil, "bigint", "42"},
+		{"nil big.Int", (*big.Int)(nil), nil, "object", "null"},
+		{"big.Float", f, nil, "number", "0.1"},
+		{"big.Float as string", f, []Option{WithBigFloatsAsStrings(true)}, "string", f.Text('g', -1)},
+		{"nil big.Float", (*big.Float)(nil), nil, "object", "null"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := ToJSValueWithErr(tt.x, tt.opts...)
+			if err != nil {
+				t.Fatalf("ToJSValueWithErr() error = %v", err)
+			}
+			if got := typeOf.Invoke(value).String(); got != tt.wantType {
+				t.Errorf("typeof ToJSValueWithErr() = %s, want %s", got, tt.wantType)
+			}
+			if got := js.Global().Call("String", value).String(); got != tt.want {
+				t.Errorf("String(ToJSValueWithErr()) = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToJSValueComplexFormats(t *testing.T) {
+	tests := []struct {
+		name   string
+		format ComplexFormat
+		want   string
+	}{
+		{"default", ComplexFormat{}, `[{"real":1,"imag":2},{"real":3,"imag":-4}]`},
+		{"renamed properties", ComplexFormat{RealName: "re", ImagName: "im"}, `[{"re":1,"im":2},{"re":3,"im":-4}]`},
+		{"one renamed property", ComplexFormat{ImagName: "i"}, `[{"real":1,"i":2},{"real":3,"i":-4}]`},
+		{"array", ComplexFormat{AsArray: true, RealName: "ignored"}, `[[1,2],[3,-4]]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := []interface{}{complex64(1 + 2i), complex128(3 - 4i)}
+			if got := jsonString(t, ToJSValueWith(x, WithComplexFormat(tt.format))); got != tt.want {
+				t.Errorf("ToJSValueWith() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToJSValueRawJSON(t *testing.T) {
+	type envelope struct {
+		Kind    string          `wasm:"kind"`
+		Payload json.RawMessage `wasm:"payload"`
+	}
+	tests := []struct {
+		name string
+		x    interface{}
+		want string
+	}{
+		{"object", json.RawMessage(`{"a": [1, "b", null]}`), `{"a":[1,"b",null]}`},
+		{"number", json.RawMessage(`1.5`), `1.5`},
+		{"field", envelope{Kind: "k", Payload: json.RawMessage(`{"x":true}`)}, `{"kind":"k","payload":{"x":true}}`},
+		{"nil", json.RawMessage(nil), `null`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := ToJSValueErr(tt.x)
+			if err != nil {
+				t.Fatalf("ToJSValueErr() error = %v", err)
+			}
+			if got := jsonString(t, value); got != tt.want {
+				t.Errorf("ToJSValueErr() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	for _, invalid := range []json.RawMessage{json.RawMessage(`{"a":`), json.RawMessage{}} {
+		var conversionErr ConversionError
+		if _, err := ToJSValueErr(envelope{Payload: invalid}); !errors.As(err, &conversionErr) {
+			t.Errorf("ToJSValueErr(%q) error = %v, want a ConversionError", invalid, err)
+		}
+	}
+}
+
+func TestToJSValueIPAddresses(t *testing.T) {
+	type host struct {
+		IP   net.IP
+		Addr netip.Addr
+	}
+	tests := []struct {
+		name string
+		x    interface{}
+		want string
+	}{
+		{"IPv4", net.ParseIP("192.0.2.1"), `"192.0.2.1"`},
+		{"IPv6", net.ParseIP("2001:db8::1"), `"2001:db8::1"`},
+		{"nil IP", net.IP(nil), `""`},
+		{"IPv4 Addr", netip.MustParseAddr("192.0.2.1"), `"192.0.2.1"`},
+		{"IPv6 Addr", netip.MustParseAddr("2001:db8::1"), `"2001:db8::1"`},
+		{"zero Addr", netip.Addr{}, `""`},
+		{"fields", host{IP: net.IPv4(10, 0, 0, 1), Addr: netip.IPv6Loopback()}, `{"IP":"10.0.0.1","Addr":"::1"}`},
+		{"slice", []net.IP{net.IPv4(10, 0, 0, 1), nil}, `["10.0.0.1",""]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonString(t, ToJSValue(tt.x)); got != tt.want {
+				t.Errorf("ToJSValue() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToJSValueTimePointers(t *testing.T) {
+	type record struct {
+		At *time.Time
+	}
+	at := time.Date(2024, time.March, 5, 6, 7, 8, 0, time.UTC)
+	zero := time.Time{}
+	describe := jsFunc("x", `return x.At instanceof Date ? x.At.toISOString() : String(x.At)`)
+	tests := []struct {
+		name            string
+		x               record
+		zeroTimesAsNull bool
+		want            string
+	}{
+		{"nil", record{}, false, "undefined"},
+		{"nil with zero times as null", record{}, true, "undefined"},
+		{"zero", record{At: &zero}, false, "0001-01-01T00:00:00.000Z"},
+		{"zero as null", record{At: &zero}, true, "null"},
+		{"time", record{At: &at}, false, "2024-03-05T06:07:08.000Z"},
+		{"time with zero times as null", record{At: &at}, true, "2024-03-05T06:07:08.000Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := ToJSValueWith(tt.x, WithZeroTimesAsNull(tt.zeroTimesAsNull))
+			if got := describe.Invoke(value).String(); got != tt.want {
+				t.Errorf("At = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+type testCoordinates struct {
+	Lat, Lng float64
+}
+
+var errTestCoordinates = errors.New("no coordinates")
+
+func (c testCoordinates) MarshalJS() (js.Value, error) {
+	if c.Lat == 0 && c.Lng == 0 {
+		return js.Value{}, errTestCoordinates
+	}
+	return js.ValueOf([]interface{}{c.Lat, c.Lng}), nil
+}
+
+func TestToJSValueErrPath(t *testing.T) {
+	type address struct {
+		Coordinates testCoordinates
+	}
+	type user struct {
+		Name      string
+		Addresses []address
+		Tags      map[string]testCoordinates
+	}
+	valid := address{Coordinates: testCoordinates{Lat: 1, Lng: 2}}
+	tests := []struct {
+		name string
+		x    interface{}
+		want string
+	}{
+		{"struct", user{Addresses: []address{valid, valid, {}}},
+			"at gowasm.user.Addresses[2].Coordinates: no coordinates"},
+		{"map", user{Tags: map[string]testCoordinates{"home": {}}}, "at gowasm.user.Tags[home]: no coordinates"},
+		{"slice", []user{{}, {Addresses: []address{{}}}}, "at []gowasm.user[1].Addresses[0].Coordinates: no coordinates"},
+		{"root", testCoordinates{}, "cannot convert gowasm.testCoordinates to a JS value (kind struct): no coordinates"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ToJSValueErr(tt.x)
+			var conversionErr ConversionError
+			if !errors.As(err, &conversionErr) || !errors.Is(err, errTestCoordinates) {
+				t.Fatalf("ToJSValueErr() error = %v, want a ConversionError wrapping errTestCoordinates", err)
+			}
+			if got := err.Error(); !strings.HasSuffix(got, tt.want) {
+				t.Errorf("ToJSValueErr() error = %q, want it to end with %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToURLSearchParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		values url.Values
+		want   string
+	}{
+		{"repeated keys", url.Values{"tag": {"a", "b"}, "q": {"x y"}}, "q=x+y&tag=a&tag=b"},
+		{"empty value", url.Values{"flag": {""}}, "flag="},
+		{"key without values", url.Values{"none": {}}, ""},
+		{"escaping", url.Values{"a&b": {"c=d"}}, "a%26b=c%3Dd"},
+		{"nil", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := ToURLSearchParams(tt.values)
+			if got := params.Call("toString").String(); got != tt.want {
+				t.Errorf("ToURLSearchParams() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	params := ToURLSearchParams(url.Values{"tag": {"a", "b"}})
+	if got := jsonString(t, params.Call("getAll", "tag")); got != `["a","b"]` {
+		t.Errorf("getAll(\"tag\") = %s, want [\"a\",\"b\"]", got)
+	}
+}
+
+func TestToJSValueBuffers(t *testing.T) {
+	partlyRead := bytes.NewBufferString("xabc")
+	partlyRead.ReadByte()
+	var builder strings.Builder
+	builder.WriteString("built")
+	describe := jsFunc("x", `return x instanceof Uint8Array ? "Uint8Array " + Array.from(x) : JSON.stringify(x)`)
+	tests := []struct {
+		name    string
+		x       interface{}
+		asBytes bool
+		want    string
+	}{
+		{"string", bytes.NewBufferString("abc"), false, `"abc"`},
+		{"unread string", partlyRead, false, `"abc"`},
+		{"value", *bytes.NewBufferString("abc"), false, `"abc"`},
+		{"bytes", bytes.NewBuffer([]byte{1, 2, 3}), true, "Uint8Array 1,2,3"},
+		{"unread bytes", partlyRead, true, "Uint8Array 97,98,99"},
+		{"nil", (*bytes.Buffer)(nil), false, "null"},
+		{"nil as bytes", (*bytes.Buffer)(nil), true, "null"},
+		{"strings.Builder", &builder, true, `"built"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := ToJSValueWith(tt.x, WithBuffersAsBytes(tt.asBytes))
+			if got := describe.Invoke(value).String(); got != tt.want {
+				t.Errorf("ToJSValueWith() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+type testShout string
+
+func (s testShout) String() string {
+	return strings.ToUpper(string(s))
+}
+
+func TestToJSValueStringerEnums(t *testing.T) {
+	type paint struct {
+		Color   testColor
+		Pointer *testColor
+		ByName  map[string]testColor
+		Word    testShout
+	}
+	green := testColor(1)
+	x := paint{Color: 0, Pointer: &green, ByName: map[string]testColor{"g": 1}, Word: "hi"}
+	tests := []struct {
+		name    string
+		enabled bool
+		want    string
+	}{
+		{"as numbers", false, `{"Color":0,"Pointer":1,"ByName":{"g":1},"Word":"hi"}`},
+		{"as strings", true, `{"Color":"red","Pointer":"green","ByName":{"g":"green"},"Word":"hi"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonString(t, ToJSValueWith(x, WithStringerEnums(tt.enabled))); got != tt.want {
+				t.Errorf("ToJSValueWith() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToJSValueSortedMapKeys(t *testing.T) {
+	at := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		x    interface{}
+		want string
+	}{
+		{"strings", map[string]int{"b": 2, "a": 1, "c": 3, "ab": 4}, `{"a":1,"ab":4,"b":2,"c":3}`},
+		{"floats", map[float64]int{2.5: 1, -1.5: 2, 10.25: 3}, `{"-1.5":2,"2.5":1,"10.25":3}`},
+		{"bools", map[bool]int{true: 1, false: 0}, `{"false":0,"true":1}`},
+		{"times", map[time.Time]int{at.Add(time.Hour): 2, at: 1},
+			`{"2024-01-01T00:00:00Z":1,"2024-01-01T01:00:00Z":2}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Maps are iterated in a different order every time, which the sorted keys hide.
+			for i := 0; i < 10; i++ {
+				if got := jsonString(t, ToJSValueWith(tt.x, WithSortedMapKeys(true))); got != tt.want {
+					t.Fatalf("ToJSValueWith() = %s, want %s", got, tt.want)
+				}
+			}
+		})
+	}
+
+	// A Map keeps the order its entries are set in, even for integer keys that JS objects would sort themselves.
+	SetSortedMapKeys(true)
+	t.Cleanup(func() {
+		SetSortedMapKeys(false)
+	})
+	m := map[int]string{10: "ten", 2: "two", -1: "minus one"}
+	keys := jsFunc("m", "return [...m.keys()]")
+	for i := 0; i < 10; i++ {
+		if got := jsonString(t, keys.Invoke(ToJSMap(m))); got != "[-1,2,10]" {
+			t.Fatalf("keys of ToJSMap() = %s, want [-1,2,10]", got)
+		}
+	}
+}
+
+func TestToJSValueNilFuncsAndInterfaces(t *testing.T) {
+	type handlers struct {
+		OnClick func()
+		Data    interface{}
+		Err     error
+		Name    string
+	}
+	describe := jsFunc("x", `return Object.entries(x).map(([k, v]) => k + ":" + (v === null ? "null" : typeof v))
+		.join(" ")`)
+	tests := []struct {
+		name string
+		x    interface{}
+		omit bool
+		want string
+	}{
+		{"nil fields", handlers{}, false, "OnClick:null Data:null Err:null Name:string"},
+		{"nil fields omitted", handlers{}, true, "Name:string"},
+		{"set fields", handlers{OnClick: func() {}, Data: 1, Err: errors.New("e")}, true,
+			"OnClick:function Data:number Err:object Name:string"},
+		{"map values", map[string]interface{}{"f": (func())(nil), "i": nil}, true, "f:null i:null"},
+		{"slice elements", []interface{}{(func())(nil), nil}, true, "0:null 1:null"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := ToJSValueWith(tt.x, WithOmitNilFuncsAndInterfaces(tt.omit), WithSortedMapKeys(true))
+			if got := describe.Invoke(value).String(); got != tt.want {
+				t.Errorf("ToJSValueWith() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type testMoney struct {
+	Cents    int64
+	Currency string
+}
+
+func (m testMoney) MarshalJSON() ([]byte, error) {
+	if m.Currency == "" {
+		return nil, errors.New("no currency")
+	}
+	amount := fmt.Sprintf("%d.%02d", m.Cents/100, m.Cents%100)
+	return json.Marshal(map[string]string{"amount": amount, "currency": m.Currency})
+}
+
+func TestToJSValueJSONMarshalers(t *testing.T) {
+	type order struct {
+		Total testMoney `wasm:"total"`
+	}
+	x := order{Total: testMoney{Cents: 1250, Currency: "IDR"}}
+	tests := []struct {
+		name    string
+		enabled bool
+		want    string
+	}{
+		{"fields", false, `{"total":{"Cents":1250,"Currency":"IDR"}}`},
+		{"MarshalJSON", true, `{"total":{"amount":"12.50","currency":"IDR"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonString(t, ToJSValueWith(x, WithJSONMarshalers(tt.enabled))); got != tt.want {
+				t.Errorf("ToJSValueWith() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	_, err := ToJSValueWithErr(order{}, WithJSONMarshalers(true))
+	if err == nil || !strings.HasSuffix(err.Error(), "at gowasm.order.Total: no currency") {
+		t.Errorf("ToJSValueWithErr() error = %v, want the MarshalJSON error at gowasm.order.Total", err)
+	}
+
+	// Special types keep their own conversion.
+	at := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if value := ToJSValueWith(at, WithJSONMarshalers(true)); !value.InstanceOf(js.Global().Get("Date")) {
+		t.Errorf("ToJSValueWith(time.Time) = %v, want a Date", value)
+	}
+}
+
+type testOpaque struct {
+	secret int
+}
+
+func (o testOpaque) Secret() int {
+	return o.secret
+}
+
+func TestToJSValueStrictStructs(t *testing.T) {
+	type hidden struct {
+		a, b int
+	}
+	type skipped struct {
+		A int `wasm:"-"`
+	}
+	tests := []struct {
+		name    string
+		x       interface{}
+		opts    []Option
+		wantErr bool
+	}{
+		{"unexported fields", hidden{}, nil, true},
+		{"skipped fields", skipped{}, nil, true},
+		{"nested", struct{ Inner hidden }{}, nil, true},
+		{"unexported fields included", hidden{}, []Option{WithPrivateFields(true)}, false},
+		{"no fields", struct{}{}, nil, false},
+		{"exported method", testOpaque{}, nil, false},
+		{"exported field", struct{ A int }{}, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ToJSValueWithErr(tt.x, append([]Option{WithStrictStructs(true)}, tt.opts...)...)
+			if got := errors.Is(err, ErrEmptyStruct); got != tt.wantErr {
+				t.Errorf("ToJSValueWithErr() error = %v, want ErrEmptyStruct %t", err, tt.wantErr)
+			}
+
+			// Without strict structs, every struct converts.
+			if _, err := ToJSValueWithErr(tt.x, tt.opts...); err != nil {
+				t.Errorf("ToJSValueWithErr() without strict structs error = %v", err)
+			}
+		})
+	}
+}
+
+func TestToJSValueTimeMapKeys(t *testing.T) {
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	tests := []struct {
+		name string
+		key  time.Time
+		want string
+	}{
+		{"UTC", time.Date(2024, time.March, 5, 6, 7, 8, 0, time.UTC), "2024-03-05T06:07:08Z"},
+		{"nanoseconds", time.Date(2024, time.March, 5, 6, 7, 8, 123456789, time.UTC), "2024-03-05T06:07:08.123456789Z"},
+		{"time zone", time.Date(2024, time.March, 5, 13, 7, 8, 0, jakarta), "2024-03-05T13:07:08+07:00"},
+		{"beyond year 9999", time.Date(10000, time.January, 1, 0, 0, 0, 0, time.UTC), "10000-01-01T00:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := ToJSValueErr(map[time.Time]float64{tt.key: 1.5})
+			if err != nil {
+				t.Fatalf("ToJSValueErr() error = %v", err)
+			}
+			if got := jsonString(t, js.Global().Get("Object").Call("keys", value)); got != `["`+tt.want+`"]` {
+				t.Errorf("keys of ToJSValueErr() = %s, want [%q]", got, tt.want)
+			}
+			if got := value.Get(tt.want).Float(); got != 1.5 {
+				t.Errorf("value of %s = %v, want 1.5", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestToJSValueFloatMapKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		x    interface{}
+		want string
+	}{
+		{"fraction", map[float64]string{1.5: "a"}, "1.5"},
+		{"integral", map[float64]string{2: "a"}, "2"},
+		{"negative", map[float64]string{-0.25: "a"}, "-0.25"},
+		{"large", map[float64]string{1e21: "a"}, "1000000000000000000000"},
+		{"small", map[float64]string{1e-7: "a"}, "0.0000001"},
+		{"positive infinity", map[float64]string{math.Inf(1): "a"}, "Infinity"},
+		{"negative infinity", map[float64]string{math.Inf(-1): "a"}, "-Infinity"},
+		{"negative zero", map[float64]string{math.Copysign(0, -1): "a"}, "0"},
+		{"float32", map[float32]string{0.1: "a"}, "0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, err := ToJSValueErr(tt.x)
+			if err != nil {
+				t.Fatalf("ToJSValueErr() error = %v", err)
+			}
+			if got := jsonString(t, js.Global().Get("Object").Call("keys", value)); got != `["`+tt.want+`"]` {
+				t.Errorf("keys of ToJSValueErr() = %s, want [%q]", got, tt.want)
+			}
+		})
+	}
+
+	// Keys that JS would write without scientific notation can be looked up with the number itself.
+	value := ToJSValue(map[float64]string{1.5: "a", math.Inf(-1): "b", -1: "c"})
+	lookup := jsFunc("m", "return [m[1.5], m[-Infinity], m[-1], m[-0]].join()")
+	if got := lookup.Invoke(value).String(); got != "a,b,c," {
+		t.Errorf("looking up number keys = %q, want \"a,b,c,\"", got)
+	}
+}
@@ -0,0 +1,91 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"syscall/js"
+)
+
+var (
+	convertersMu sync.RWMutex
+	converters   = make(map[reflect.Type]func(interface{}) js.Value)
+)
+
+// RegisterConverter registers fn to be used by ToJSValue to convert every value of exactly type t, replacing any
+// converter previously registered for t. This allows customizing the conversion of types that cannot implement
+// Wrapper, such as types from other packages. A Wrapper implementation still takes precedence.
+//
+// It is safe to call RegisterConverter concurrently with conversions.
+func RegisterConverter(t reflect.Type, fn func(interface{}) js.Value) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	converters[t] = fn
+	invalidateFieldCache()
+}
+
+// UnregisterConverter removes the converter registered for type t, if any.
+func UnregisterConverter(t reflect.Type) {
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	delete(converters, t)
+	invalidateFieldCache()
+}
+
+// lookupConverter returns the converter registered for type t.
+func lookupConverter(t reflect.Type) (func(interface{}) js.Value, bool) {
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	fn, ok := converters[t]
+	return fn, ok
+}
+
+var (
+	classesMu sync.RWMutex
+	classes   = make(map[reflect.Type]js.Value)
+)
+
+// RegisterClass registers the JS class or constructor function ctor for the struct type t, or the struct type t points
+// to, replacing any class previously registered for it. ToJSValue then converts values of that struct type into objects
+// created with Object.create(ctor.prototype), so that they are instances of ctor on the JS side and inherit its
+// methods, before setting their fields and methods as usual. The constructor itself is not called.
+//
+// It panics if t is not a struct type or a pointer to one, or if ctor is not a function.
+// It is safe to call RegisterClass concurrently with conversions.
+func RegisterClass(t reflect.Type, ctor js.Value) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("RegisterClass requires a struct type, got %v", t))
+	}
+	if ctor.Type() != js.TypeFunction {
+		panic(fmt.Sprintf("RegisterClass requires a constructor function, got a JS %v", ctor.Type()))
+	}
+
+	classesMu.Lock()
+	defer classesMu.Unlock()
+	classes[t] = ctor
+}
+
+// UnregisterClass removes the class registered for the struct type t, or the struct type t points to, if any.
+func UnregisterClass(t reflect.Type) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	classesMu.Lock()
+	defer classesMu.Unlock()
+	delete(classes, t)
+}
+
+// lookupClass returns the class registered for the struct type t.
+func lookupClass(t reflect.Type) (js.Value, bool) {
+	classesMu.RLock()
+	defer classesMu.RUnlock()
+	ctor, ok := classes[t]
+	return ctor, ok
+}
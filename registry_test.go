@@ -0,0 +1,79 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"reflect"
+	"syscall/js"
+	"testing"
+)
+
+type testPoint struct {
+	X, Y int
+}
+
+func TestRegisterClass(t *testing.T) {
+	class := jsFunc(`return class Point {
+		constructor() { throw new Error("the constructor is not called"); }
+		norm() { return this.X + this.Y; }
+	}`).Invoke()
+	RegisterClass(reflect.TypeOf(&testPoint{}), class)
+	t.Cleanup(func() {
+		UnregisterClass(reflect.TypeOf(testPoint{}))
+	})
+
+	instanceOf := jsFunc("x", "ctor", "return x instanceof ctor")
+	tests := []struct {
+		name string
+		x    interface{}
+	}{
+		{"value", testPoint{X: 1, Y: 2}},
+		{"pointer", &testPoint{X: 1, Y: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value := ToJSValue(tt.x)
+			if !instanceOf.Invoke(value, class).Bool() {
+				t.Errorf("ToJSValue() is not an instance of the registered class")
+			}
+			if got := value.Call("norm").Int(); got != 3 {
+				t.Errorf("norm() = %d, want 3", got)
+			}
+		})
+	}
+
+	// Every element of a slice, converted through the compiled struct converter too, is an instance.
+	values := ToJSValue([]testPoint{{X: 1}, {Y: 2}})
+	for i := 0; i < values.Length(); i++ {
+		if !instanceOf.Invoke(values.Index(i), class).Bool() {
+			t.Errorf("element %d is not an instance of the registered class", i)
+		}
+	}
+
+	UnregisterClass(reflect.TypeOf(testPoint{}))
+	if instanceOf.Invoke(ToJSValue(testPoint{}), class).Bool() {
+		t.Errorf("ToJSValue() after UnregisterClass is an instance of the class")
+	}
+}
+
+func TestRegisterClassPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  reflect.Type
+		ctor js.Value
+	}{
+		{"not a struct", reflect.TypeOf(0), jsFunc("")},
+		{"not a function", reflect.TypeOf(testPoint{}), js.ValueOf("Point")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterClass() did not panic")
+				}
+			}()
+			RegisterClass(tt.typ, tt.ctor)
+		})
+	}
+}
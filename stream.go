@@ -0,0 +1,46 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"fmt"
+	"reflect"
+	"syscall/js"
+)
+
+// ToJSArrayStream converts the elements of the provided slice or array one at a time like ToJSValue does and passes
+// each of them to fn along with its index, stopping early once fn returns false.
+//
+// It is an advanced path for feeding very large slices to a JS consumer incrementally, such as pushing them into a
+// stream or a worker, without building the whole JS array in memory first. Unlike with ToJSValue, a value shared by
+// several elements is converted again for each of them.
+//
+// It returns a ConversionError if an element cannot be converted, after passing the previous elements to fn, or if x
+// is not a slice or an array.
+func ToJSArrayStream(x interface{}, fn func(i int, v js.Value) bool) error {
+	e := encoder{config: currentConfig()}
+
+	value := reflect.ValueOf(x)
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		if !value.IsValid() {
+			return fmt.Errorf("cannot stream nil: %w", ErrUnsupportedType)
+		}
+		return e.errorf(value, ErrUnsupportedType)
+	}
+	e.root = value.Type()
+
+	for i := 0; i < value.Len(); i++ {
+		elem, err := e.toJSValueAt(pathSegment{index: i}, interfaceOf(value.Index(i)))
+		if err != nil {
+			return err
+		}
+
+		// Forget the converted values so that they can be garbage collected once fn is done with them.
+		e.visited = nil
+		if !fn(i, elem) {
+			return nil
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import "strings"
+
+// tagOptions is the comma-separated list of options following the name in a wasm struct tag.
+type tagOptions string
+
+// parseTag splits a wasm struct tag such as "name,omitempty" into its name and options.
+func parseTag(tag string) (string, tagOptions) {
+	name, opts, _ := strings.Cut(tag, ",")
+	return name, tagOptions(opts)
+}
+
+// Contains reports whether the provided option is in the list of options.
+func (o tagOptions) Contains(option string) bool {
+	s := string(o)
+	for s != "" {
+		var current string
+		current, s, _ = strings.Cut(s, ",")
+		if current == option {
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the value of the provided key=value option, such as "2006-01-02" for the key "format" in
+// "omitempty,format=2006-01-02". As options are separated by commas, values cannot contain one.
+func (o tagOptions) Get(key string) (string, bool) {
+	s := string(o)
+	for s != "" {
+		var current string
+		current, s, _ = strings.Cut(s, ",")
+		if k, value, ok := strings.Cut(current, "="); ok && k == key {
+			return value, true
+		}
+	}
+	return "", false
+}
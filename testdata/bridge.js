@@ -0,0 +1,14 @@
+"use strict";
+
+// bridge.js defines the JS bridge that the package expects to find when it is initialized, for the tests run in
+// Node.js by go_js_wasm_exec. The wrapper turns the {result, error} objects returned by the functions converted by
+// ToJSValue into a return value or a thrown error.
+globalThis.__go_wasm__ = {
+	__wrapper__: (fn) => function (...args) {
+		const { result, error } = fn.apply(this, args);
+		if (error !== undefined && error !== null) {
+			throw error;
+		}
+		return result;
+	},
+};
@@ -3,7 +3,10 @@
 
 package gowasm
 
-import "syscall/js"
+import (
+	"fmt"
+	"syscall/js"
+)
 
 // Magic values to communicate with the JS library.
 const (
@@ -44,3 +47,36 @@ func Ready() {
 func Expose(property string, x interface{}) {
 	bridge.Set(property, x)
 }
+
+// ExportGlobal converts the provided value with ToJSValueErr and sets it as the property name of the JS global object,
+// globalThis, so that JS code can reach it without going through the JS bridge.
+// It returns an error if the value cannot be converted or if the global object is not an object.
+func ExportGlobal(name string, x interface{}) error {
+	return ExportGlobals(map[string]interface{}{name: x})
+}
+
+// ExportGlobals is like ExportGlobal for every value of the provided map, set as the property named by its key.
+// The values are all converted before any of them is set, so that none is set if one cannot be converted, in which
+// case the js.Funcs created by the conversions are released.
+func ExportGlobals(values map[string]interface{}) error {
+	global, err := globalObject()
+	if err != nil {
+		return fmt.Errorf("global object not reachable: %w", err)
+	}
+
+	var c Converter
+	converted := make(map[string]js.Value, len(values))
+	for name, x := range values {
+		value, err := c.ToJSValueErr(x)
+		if err != nil {
+			c.Release()
+			return fmt.Errorf("cannot export global %s: %w", name, err)
+		}
+		converted[name] = value
+	}
+
+	for name, value := range converted {
+		global.value.Set(name, value)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+//go:build js && wasm
+// +build js,wasm
+
+package gowasm
+
+import (
+	"errors"
+	"reflect"
+	"syscall/js"
+	"testing"
+)
+
+func TestExportGlobals(t *testing.T) {
+	err := ExportGlobals(map[string]interface{}{
+		"testAnswer": 42,
+		"testDouble": func(n int) int { return 2 * n },
+	})
+	t.Cleanup(func() {
+		js.Global().Delete("testAnswer")
+		js.Global().Delete("testDouble")
+	})
+	if err != nil {
+		t.Fatalf("ExportGlobals() error = %v", err)
+	}
+
+	if got := js.Global().Get("testAnswer").Int(); got != 42 {
+		t.Errorf("testAnswer = %d, want 42", got)
+	}
+	if got := js.Global().Call("testDouble", 3).Int(); got != 6 {
+		t.Errorf("testDouble(3) = %d, want 6", got)
+	}
+}
+
+type testExported struct {
+	F func() int
+	M map[interface{}]int
+}
+
+func TestExportGlobalsReleasesFuncsOnError(t *testing.T) {
+	// The prototype of the class captures F as it is set, before the conversion of M fails.
+	class := jsFunc(`
+		const C = function () {};
+		C.captured = {};
+		Object.defineProperty(C.prototype, "F", {set(v) { C.captured.F = v; }});
+		return C;`).Invoke()
+	RegisterClass(reflect.TypeOf(testExported{}), class)
+	t.Cleanup(func() {
+		UnregisterClass(reflect.TypeOf(testExported{}))
+	})
+
+	err := ExportGlobals(map[string]interface{}{
+		"testExported": testExported{F: func() int { return 1 }, M: map[interface{}]int{nil: 1}},
+	})
+	if !errors.Is(err, ErrUnsupportedMapKey) {
+		t.Fatalf("ExportGlobals() error = %v, want ErrUnsupportedMapKey", err)
+	}
+	if got := js.Global().Get("testExported"); !got.IsUndefined() {
+		t.Errorf("testExported = %v, want undefined", got)
+	}
+
+	f := class.Get("captured").Get("F")
+	if f.Type() != js.TypeFunction {
+		t.Fatalf("captured F = %v, want a function", f)
+	}
+	if _, ok := catch(f); !ok {
+		t.Errorf("calling F did not throw, want it released")
+	}
+}